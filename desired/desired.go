@@ -0,0 +1,181 @@
+// Package desired manages accounts as code. A desired-state file lists the
+// accounts that should exist; a plan lists the creates, updates and deletes
+// needed to bring an organisation in line with it, and can be reviewed
+// before it is applied:
+//
+//	state, err := desired.Load("accounts.yaml")
+//	plan, err := desired.NewPlan(ctx, client.Accounts, state)
+//	plan.WriteText(os.Stdout)
+//	result, err := desired.Apply(ctx, client.Accounts, plan)
+//
+// Desired-state files are YAML or JSON:
+//
+//	scope:
+//	  organisation_id: eb0bd6f5-c3f5-44b2-b677-acd23cdde73c
+//	  customer_id: operational
+//	accounts:
+//	  - id: 7c8a4c57-7a5f-4b43-9a4a-7b0c3a9e8e31
+//	    attributes:
+//	      country: GB
+//	      bank_id: "400300"
+//	      bic: NWBKGB22
+//	      customer_id: operational
+//	      name: [Settlement]
+//
+// Accounts are matched by ID, so every account must have one. Only the
+// attributes given are managed; others, such as generated IBANs, are left
+// as they are. Values that look like numbers, such as bank IDs, must be
+// quoted in YAML.
+//
+// Accounts that are not in the file are deleted only if the file has a
+// scope, and then only those in the scope, so that a file can manage some of
+// an organisation's accounts without touching the rest. A scope without an
+// organisation ID covers every organisation the client's credentials can
+// see. Accounts without an organisation ID are created in the scope's
+// organisation, or else the client's.
+package desired
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"form3.tech/go-form3/form3"
+	"gopkg.in/yaml.v3"
+)
+
+// A State is the set of accounts that should exist.
+type State struct {
+	// Scope restricts the accounts managed. Without one, accounts that are
+	// not in the state are never deleted.
+	Scope *Scope `json:"scope,omitempty"`
+
+	Accounts []*form3.Account `json:"accounts"`
+}
+
+// A Scope restricts the accounts managed by a State to those matching
+// every attribute set.
+type Scope struct {
+	OrganisationID string           `json:"organisation_id,omitempty"`
+	BankIdCode     form3.BankIdCode `json:"bank_id_code,omitempty"`
+	BankID         string           `json:"bank_id,omitempty"`
+	CustomerID     string           `json:"customer_id,omitempty"`
+	Country        form3.Country    `json:"country,omitempty"`
+}
+
+// filter returns the account filter selecting the accounts in s.
+func (s *Scope) filter() *form3.AccountFilter {
+	return &form3.AccountFilter{
+		OrganisationID: s.OrganisationID,
+		BankIdCode:     s.BankIdCode,
+		BankID:         s.BankID,
+		CustomerID:     s.CustomerID,
+		Country:        s.Country,
+	}
+}
+
+// contains reports whether a is in s, describing the mismatch if not. An
+// account without an organisation ID is in the scope's organisation, as it
+// is created there.
+func (s *Scope) contains(a *form3.Account) (bool, string) {
+	if organisationID := stringValue(a.OrganisationId); s.OrganisationID != "" && organisationID != "" && organisationID != s.OrganisationID {
+		return false, fmt.Sprintf("organisation_id is %q, but the scope requires %q", organisationID, s.OrganisationID)
+	}
+	attrs := a.Attributes
+	if attrs == nil {
+		attrs = new(form3.AccountAttributes)
+	}
+	checks := []struct {
+		field, want, got string
+	}{
+		{"bank_id_code", string(s.BankIdCode), enumValue(attrs.BankIdCode)},
+		{"bank_id", s.BankID, stringValue(attrs.BankId)},
+		{"customer_id", s.CustomerID, stringValue(attrs.CustomerId)},
+		{"country", string(s.Country), enumValue(attrs.Country)},
+	}
+	for _, c := range checks {
+		if c.want != "" && c.got != c.want {
+			return false, fmt.Sprintf("%s is %q, but the scope requires %q", c.field, c.got, c.want)
+		}
+	}
+	return true, ""
+}
+
+// Load reads the state in the YAML or JSON file at path.
+func Load(path string) (*State, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return state, nil
+}
+
+// Parse parses and validates a YAML or JSON encoded state.
+func Parse(data []byte) (*State, error) {
+	// YAML is a superset of JSON, so both are decoded as YAML and converted
+	// to JSON to be decoded with the accounts' JSON field names.
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	state := new(State)
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	if err := state.Validate(); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Validate checks that every account in s has a unique ID, valid attributes
+// and is in the scope of s.
+func (s *State) Validate() error {
+	seen := make(map[string]bool)
+	for i, a := range s.Accounts {
+		if a == nil || a.ID == nil || *a.ID == "" {
+			return fmt.Errorf("account %d has no ID", i)
+		}
+		id := *a.ID
+		if seen[id] {
+			return fmt.Errorf("account %s is listed more than once", id)
+		}
+		seen[id] = true
+
+		if a.Attributes == nil {
+			return fmt.Errorf("account %s has no attributes", id)
+		}
+		if err := form3.ValidateAccountAttributes(a.Attributes); err != nil {
+			return fmt.Errorf("account %s: %v", id, err)
+		}
+		if s.Scope != nil {
+			if ok, reason := s.Scope.contains(a); !ok {
+				return fmt.Errorf("account %s is outside the scope: %s", id, reason)
+			}
+		}
+	}
+	return nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func enumValue[T ~string](v *T) string {
+	if v == nil {
+		return ""
+	}
+	return string(*v)
+}
@@ -0,0 +1,95 @@
+package desired
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"form3.tech/go-form3/form3"
+)
+
+const testState = `
+scope:
+  customer_id: operational
+accounts:
+  - id: "1"
+    attributes:
+      country: GB
+      bank_id: "400300"
+      bank_id_code: GBDSC
+      bic: NWBKGB22
+      customer_id: operational
+      name: [Settlement]
+  - id: "2"
+    attributes:
+      country: GB
+      bank_id: "400300"
+      bank_id_code: GBDSC
+      bic: NWBKGB22
+      customer_id: operational
+      name: [Suspense]
+`
+
+func TestUnit_Parse_YAML(t *testing.T) {
+	state, err := Parse([]byte(testState))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if state.Scope == nil || state.Scope.CustomerID != "operational" {
+		t.Errorf("Parse read scope %+v, want customer_id operational", state.Scope)
+	}
+	if len(state.Accounts) != 2 {
+		t.Fatalf("Parse read %d accounts, want 2", len(state.Accounts))
+	}
+	a := state.Accounts[1]
+	if *a.ID != "2" || *a.Attributes.Country != form3.CountryGB || *a.Attributes.BankId != "400300" || a.Attributes.Name[0] != "Suspense" {
+		t.Errorf("Parse read account %+v", *a.Attributes)
+	}
+}
+
+func TestUnit_Parse_JSON(t *testing.T) {
+	state, err := Parse([]byte(`{"accounts": [{"id": "1", "attributes": {"country": "GB", "bank_id": "400300", "bank_id_code": "GBDSC", "bic": "NWBKGB22", "name": ["Fees"]}}]}`))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if state.Scope != nil || len(state.Accounts) != 1 || state.Accounts[0].Attributes.Name[0] != "Fees" {
+		t.Errorf("Parse read %+v", state)
+	}
+}
+
+func TestUnit_Parse_Invalid(t *testing.T) {
+	tests := []struct {
+		name, state, want string
+	}{
+		{"missing ID", `accounts: [{attributes: {country: GB, bank_id: "400300", bic: NWBKGB22, name: [A]}}]`, "has no ID"},
+		{"duplicate ID", `accounts: [{id: "1", attributes: {country: FR, bank_id: "2004101005", bank_id_code: FR, name: [A]}}, {id: "1", attributes: {country: FR, bank_id: "2004101005", bank_id_code: FR, name: [B]}}]`, "more than once"},
+		{"invalid attributes", `accounts: [{id: "1", attributes: {country: GB, name: [A]}}]`, "bank_id"},
+		{"outside organisation scope", `{scope: {organisation_id: org-1}, accounts: [{id: "1", organisation_id: org-2, attributes: {country: FR, bank_id: "2004101005", bank_id_code: FR, name: [A]}}]}`, "organisation_id"},
+		{"outside scope", `{scope: {customer_id: ops}, accounts: [{id: "1", attributes: {country: FR, bank_id: "2004101005", bank_id_code: FR, name: [A]}}]}`, "outside the scope"},
+		{"unquoted number", `accounts: [{id: "1", attributes: {country: GB, bank_id: 400300}}]`, "bank_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.state))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse returned error %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnit_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.yaml")
+	if err := os.WriteFile(path, []byte(testState), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(state.Accounts) != 2 {
+		t.Errorf("Load read %d accounts, want 2", len(state.Accounts))
+	}
+}
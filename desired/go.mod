@@ -0,0 +1,12 @@
+module form3.tech/go-form3/desired
+
+go 1.21
+
+require (
+	form3.tech/go-form3 v0.0.0-00010101000000-000000000000
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require github.com/google/go-querystring v1.0.0 // indirect
+
+replace form3.tech/go-form3 => ../
@@ -0,0 +1,163 @@
+package desired
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"form3.tech/go-form3/drift"
+	"form3.tech/go-form3/form3"
+)
+
+// A Plan lists the changes needed to bring an organisation's accounts in
+// line with a State.
+type Plan struct {
+	Create []*form3.Account       // accounts to create
+	Update []*drift.AccountChange // accounts to update, from their current to their desired attributes
+	Delete []*form3.Account       // accounts to delete, at their current version
+}
+
+// IsEmpty reports whether the accounts already match the state.
+func (p *Plan) IsEmpty() bool {
+	return len(p.Create) == 0 && len(p.Update) == 0 && len(p.Delete) == 0
+}
+
+// NewPlan lists the accounts of s in the scope of state and plans the
+// changes needed to bring them in line with it. Updates and deletes are
+// made at the versions listed, so applying a plan fails for accounts that
+// have changed since it was made.
+func NewPlan(ctx context.Context, s *form3.AccountsService, state *State) (*Plan, error) {
+	var filter *form3.AccountFilter
+	if state.Scope != nil {
+		filter = state.Scope.filter()
+	}
+	current := drift.FromService(s, filter)
+	desired := drift.SourceFunc(func(ctx context.Context) ([]*form3.Account, error) {
+		return state.scopedAccounts(), nil
+	})
+
+	report, err := drift.Compare(ctx, current, desired, &drift.Options{IgnoreUnset: true})
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{Create: report.Added, Update: report.Changed}
+	if state.Scope != nil {
+		plan.Delete = report.Removed
+	}
+	return plan, nil
+}
+
+// scopedAccounts returns the accounts of s, with those that have no
+// organisation ID assigned to the organisation of its scope, if any.
+func (s *State) scopedAccounts() []*form3.Account {
+	if s.Scope == nil || s.Scope.OrganisationID == "" {
+		return s.Accounts
+	}
+	accounts := make([]*form3.Account, len(s.Accounts))
+	for i, a := range s.Accounts {
+		accounts[i] = a
+		if a.OrganisationId == nil || *a.OrganisationId == "" {
+			scoped := *a
+			scoped.OrganisationId = form3.String(s.Scope.OrganisationID)
+			accounts[i] = &scoped
+		}
+	}
+	return accounts
+}
+
+// Apply makes the changes in p using s. Accounts are created in the
+// organisation of the state's scope, if any, and otherwise in the
+// organisation of s's client. Changes that fail are reported in the result,
+// and a new plan can be made and applied to retry them.
+func Apply(ctx context.Context, s *form3.AccountsService, p *Plan) (*drift.ReconcileResult, error) {
+	result := &drift.ReconcileResult{Failed: make(map[string]error)}
+	for _, a := range p.Create {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		account := &form3.Account{ID: a.ID, OrganisationId: a.OrganisationId, Attributes: a.Attributes}
+		if _, _, err := s.Create(ctx, account); err != nil {
+			result.Failed[stringValue(a.ID)] = err
+			continue
+		}
+		result.Created = append(result.Created, stringValue(a.ID))
+	}
+
+	report := &drift.Report{
+		MatchBy: drift.MatchByID,
+		Changed: p.Update,
+		Removed: p.Delete,
+	}
+	reconciled, err := drift.Reconcile(ctx, s, report)
+	result.Updated, result.Deleted = reconciled.Updated, reconciled.Deleted
+	for id, err := range reconciled.Failed {
+		result.Failed[id] = err
+	}
+	return result, err
+}
+
+// WriteText writes p to w in the style of a terraform plan.
+func (p *Plan) WriteText(w io.Writer) error {
+	if p.IsEmpty() {
+		_, err := fmt.Fprintln(w, "No changes. Accounts match the desired state.")
+		return err
+	}
+
+	pw := &planWriter{w: w}
+	for _, a := range p.Create {
+		pw.printf("  + account %s\n", stringValue(a.ID))
+		diffs, err := drift.Diff(nil, a.Attributes)
+		if err != nil {
+			return err
+		}
+		width := fieldWidth(diffs)
+		for _, d := range diffs {
+			pw.printf("      + %-*s = %s\n", width, d.Field, d.To)
+		}
+		pw.printf("\n")
+	}
+	for _, c := range p.Update {
+		pw.printf("  ~ account %s (version %s)\n", c.Key, versionString(c.From.Version))
+		width := fieldWidth(c.Fields)
+		for _, d := range c.Fields {
+			pw.printf("      ~ %-*s = %s -> %s\n", width, d.Field, d.From, d.To)
+		}
+		pw.printf("\n")
+	}
+	for _, a := range p.Delete {
+		pw.printf("  - account %s (version %s)\n\n", stringValue(a.ID), versionString(a.Version))
+	}
+	pw.printf("Plan: %d to create, %d to update, %d to delete.\n", len(p.Create), len(p.Update), len(p.Delete))
+	return pw.err
+}
+
+// planWriter writes formatted text, remembering the first error.
+type planWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (pw *planWriter) printf(format string, args ...interface{}) {
+	if pw.err == nil {
+		_, pw.err = fmt.Fprintf(pw.w, format, args...)
+	}
+}
+
+// fieldWidth returns the length of the longest field name in diffs, to align
+// their values.
+func fieldWidth(diffs []*drift.FieldDiff) int {
+	width := 0
+	for _, d := range diffs {
+		if len(d.Field) > width {
+			width = len(d.Field)
+		}
+	}
+	return width
+}
+
+func versionString(v *int) string {
+	if v == nil {
+		return "unknown"
+	}
+	return fmt.Sprint(*v)
+}
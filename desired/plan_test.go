@@ -0,0 +1,284 @@
+package desired
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"form3.tech/go-form3/form3"
+)
+
+// fakeAPI is an in-memory accounts API supporting list, create, update and
+// delete with versions.
+type fakeAPI struct {
+	mu       sync.Mutex
+	accounts map[string]*form3.Account
+	order    []string
+	filters  []string
+
+	organisationFilters []string
+}
+
+func (f *fakeAPI) client(t *testing.T) *form3.Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if r.Method == "POST" {
+			body := new(form3.AccountCreation)
+			json.NewDecoder(r.Body).Decode(body)
+			body.Data.Version = form3.Int(0)
+			f.accounts[*body.Data.ID] = body.Data
+			f.order = append(f.order, *body.Data.ID)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(&form3.AccountCreationResponse{Data: body.Data})
+			return
+		}
+		f.filters = append(f.filters, r.URL.Query().Get("filter[customer_id]"))
+		f.organisationFilters = append(f.organisationFilters, r.URL.Query().Get("filter[organisation_id]"))
+		var page []*form3.Account
+		if r.URL.Query().Get("page[number]") == "0" || r.URL.Query().Get("page[number]") == "" {
+			for _, id := range f.order {
+				if a, ok := f.accounts[id]; ok {
+					page = append(page, a)
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(&form3.AccountDetailsListResponse{Data: page})
+	})
+	mux.HandleFunc("/v1/organisation/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		id := strings.TrimPrefix(r.URL.Path, "/v1/organisation/accounts/")
+		a, ok := f.accounts[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case "PATCH":
+			body := new(form3.AccountUpdate)
+			json.NewDecoder(r.Body).Decode(body)
+			if *body.Data.Version != *a.Version {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			attrs, _ := json.Marshal(body.Data.Attributes)
+			json.Unmarshal(attrs, a.Attributes)
+			a.Version = form3.Int(*a.Version + 1)
+			json.NewEncoder(w).Encode(&form3.AccountDetailsResponse{Data: a})
+		case "DELETE":
+			if r.URL.Query().Get("version") != strconv.Itoa(*a.Version) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			delete(f.accounts, id)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := form3.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/v1/")
+	return client
+}
+
+func newFakeAPI(accounts ...*form3.Account) *fakeAPI {
+	f := &fakeAPI{accounts: make(map[string]*form3.Account)}
+	for _, a := range accounts {
+		f.accounts[*a.ID] = a
+		f.order = append(f.order, *a.ID)
+	}
+	return f
+}
+
+func existing(id string, version int, name string) *form3.Account {
+	return &form3.Account{
+		ID:      form3.String(id),
+		Version: form3.Int(version),
+		Attributes: &form3.AccountAttributes{
+			Country:    form3.CountryGB.Ptr(),
+			BankId:     form3.String("400300"),
+			BankIdCode: form3.BankIdCodeGBDSC.Ptr(),
+			BIC:        form3.String("NWBKGB22"),
+			IBAN:       form3.String("GB11NWBK40030041426819"),
+			CustomerId: form3.String("operational"),
+			Name:       []string{name},
+		},
+	}
+}
+
+func TestUnit_NewPlan_Apply(t *testing.T) {
+	api := newFakeAPI(existing("1", 2, "Settlement"), existing("2", 0, "Old suspense"), existing("3", 5, "Fees"))
+	client := api.client(t)
+	state, err := Parse([]byte(testState))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := NewPlan(context.Background(), client.Accounts, state)
+	if err != nil {
+		t.Fatalf("NewPlan returned error: %v", err)
+	}
+	if len(plan.Create) != 0 || len(plan.Update) != 1 || len(plan.Delete) != 1 {
+		t.Fatalf("NewPlan planned %d creates, %d updates and %d deletes, want 0, 1 and 1", len(plan.Create), len(plan.Update), len(plan.Delete))
+	}
+	if got := api.filters[0]; got != "operational" {
+		t.Errorf("NewPlan listed accounts with customer_id filter %q, want operational", got)
+	}
+
+	var buf bytes.Buffer
+	if err := plan.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText returned error: %v", err)
+	}
+	want := `  ~ account 2 (version 0)
+      ~ name = ["Old suspense"] -> ["Suspense"]
+
+  - account 3 (version 5)
+
+Plan: 0 to create, 1 to update, 1 to delete.
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteText wrote\n%s\nwant\n%s", got, want)
+	}
+
+	result, err := Apply(context.Background(), client.Accounts, plan)
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	if len(result.Failed) != 0 {
+		t.Errorf("Apply failed for %v", result.Failed)
+	}
+
+	replan, err := NewPlan(context.Background(), client.Accounts, state)
+	if err != nil {
+		t.Fatalf("NewPlan returned error: %v", err)
+	}
+	if !replan.IsEmpty() {
+		t.Errorf("Plan after applying is not empty: %+v", replan)
+	}
+	if got := *api.accounts["2"].Attributes.IBAN; got != "GB11NWBK40030041426819" {
+		t.Errorf("Unmanaged IBAN changed to %v", got)
+	}
+}
+
+func TestUnit_NewPlan_Create(t *testing.T) {
+	api := newFakeAPI()
+	client := api.client(t)
+	state, err := Parse([]byte(`accounts: [{id: "9", attributes: {country: FR, bank_id: "2004101005", bank_id_code: FR, name: [Fees]}}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := NewPlan(context.Background(), client.Accounts, state)
+	if err != nil {
+		t.Fatalf("NewPlan returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	plan.WriteText(&buf)
+	want := `  + account 9
+      + country      = "FR"
+      + bank_id      = "2004101005"
+      + bank_id_code = "FR"
+      + name         = ["Fees"]
+
+Plan: 1 to create, 0 to update, 0 to delete.
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteText wrote\n%s\nwant\n%s", got, want)
+	}
+
+	result, err := Apply(context.Background(), client.Accounts, plan)
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Created, []string{"9"}) {
+		t.Errorf("Apply created %v, want [9]", result.Created)
+	}
+}
+
+func TestUnit_NewPlan_NoScopeNoDeletes(t *testing.T) {
+	client := newFakeAPI(existing("1", 0, "Other")).client(t)
+	state, err := Parse([]byte(`accounts: []`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := NewPlan(context.Background(), client.Accounts, state)
+	if err != nil {
+		t.Fatalf("NewPlan returned error: %v", err)
+	}
+	if !plan.IsEmpty() {
+		t.Errorf("Plan without a scope is not empty: %+v", plan)
+	}
+
+	var buf bytes.Buffer
+	plan.WriteText(&buf)
+	if got, want := buf.String(), "No changes. Accounts match the desired state.\n"; got != want {
+		t.Errorf("WriteText wrote %q, want %q", got, want)
+	}
+}
+
+func TestUnit_Apply_StaleVersion(t *testing.T) {
+	api := newFakeAPI(existing("1", 0, "Settlement"), existing("2", 0, "Old suspense"))
+	client := api.client(t)
+	state, _ := Parse([]byte(testState))
+	plan, err := NewPlan(context.Background(), client.Accounts, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api.accounts["2"].Version = form3.Int(1)
+
+	result, err := Apply(context.Background(), client.Accounts, plan)
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	if _, ok := result.Failed["2"]; !ok {
+		t.Errorf("Apply did not fail updating an account that changed since planning")
+	}
+}
+
+func TestUnit_NewPlan_OrganisationScope(t *testing.T) {
+	api := newFakeAPI()
+	client := api.client(t)
+
+	state, err := Parse([]byte(`
+scope:
+  organisation_id: eb0bd6f5-c3f5-44b2-b677-acd23cdde73c
+  customer_id: operational
+accounts:
+  - id: "1"
+    attributes: {country: GB, bank_id: "400300", bank_id_code: GBDSC, bic: NWBKGB22, customer_id: operational, name: [Settlement]}
+`))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	plan, err := NewPlan(context.Background(), client.Accounts, state)
+	if err != nil {
+		t.Fatalf("NewPlan returned error: %v", err)
+	}
+	if want := []string{"eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"}; !reflect.DeepEqual(api.organisationFilters, want) {
+		t.Errorf("NewPlan listed accounts with organisation filters %q, want %q", api.organisationFilters, want)
+	}
+	if _, err := Apply(context.Background(), client.Accounts, plan); err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	if got := api.accounts["1"].OrganisationId; got == nil || *got != "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c" {
+		t.Errorf("Apply created account in organisation %v, want the scope's", got)
+	}
+	if state.Accounts[0].OrganisationId != nil {
+		t.Error("NewPlan modified the state")
+	}
+}
@@ -0,0 +1,291 @@
+// Package drift compares two sets of accounts, such as those registered in
+// staging and production, and reports the accounts added, removed and
+// changed between them. A report can be applied to bring the first set in
+// line with the second.
+//
+//	report, err := drift.Compare(ctx, drift.FromService(staging.Accounts, nil), drift.FromService(production.Accounts, nil), nil)
+//	...
+//	report.WriteText(os.Stdout)
+package drift
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"form3.tech/go-form3/form3"
+	"form3.tech/go-form3/snapshot"
+)
+
+// A Source provides a set of accounts to compare.
+type Source interface {
+	Accounts(ctx context.Context) ([]*form3.Account, error)
+}
+
+// SourceFunc is an adapter to allow the use of an ordinary function as a
+// Source.
+type SourceFunc func(ctx context.Context) ([]*form3.Account, error)
+
+// Accounts calls f(ctx).
+func (f SourceFunc) Accounts(ctx context.Context) ([]*form3.Account, error) {
+	return f(ctx)
+}
+
+// FromService returns a Source of every account listed by s that matches
+// filter, which may be nil.
+func FromService(s *form3.AccountsService, filter *form3.AccountFilter) Source {
+	return SourceFunc(func(ctx context.Context) ([]*form3.Account, error) {
+		var accounts []*form3.Account
+		err := s.ListAll(ctx, &form3.AccountListOptions{Filter: filter}, func(a *form3.Account) error {
+			accounts = append(accounts, a)
+			return nil
+		})
+		return accounts, err
+	})
+}
+
+// FromSnapshot returns a Source of the accounts in snap.
+func FromSnapshot(snap *snapshot.Snapshot) Source {
+	return SourceFunc(func(ctx context.Context) ([]*form3.Account, error) {
+		return snap.Accounts, nil
+	})
+}
+
+// MatchBy determines how accounts in the two sets are paired up.
+type MatchBy string
+
+const (
+	// Accounts match if they have the same ID.
+	MatchByID MatchBy = "id"
+
+	// Accounts match if they have the same bank ID and account number,
+	// whatever their IDs.
+	MatchByBankAccount MatchBy = "bank_account"
+)
+
+// Options specifies the optional parameters to Compare.
+type Options struct {
+	// How accounts are matched. Defaults to MatchByID.
+	MatchBy MatchBy
+
+	// Attributes to ignore, named as in the API, e.g. "status".
+	IgnoreFields []string
+
+	// If true, attributes not set on an account in the second set are not
+	// compared, so that it need only specify the attributes it cares about.
+	IgnoreUnset bool
+}
+
+// A FieldDiff is an attribute that differs between matched accounts. Values
+// are JSON encoded, and null if the attribute is not set.
+type FieldDiff struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
+
+// An AccountChange is a pair of matched accounts whose attributes differ.
+type AccountChange struct {
+	Key    string         `json:"key"`
+	From   *form3.Account `json:"from"`
+	To     *form3.Account `json:"to"`
+	Fields []*FieldDiff   `json:"fields"`
+}
+
+// A Report lists the differences going from one set of accounts to another.
+// Accounts are ordered by the key they were matched on.
+type Report struct {
+	MatchBy MatchBy          `json:"match_by"`
+	Added   []*form3.Account `json:"added"`   // accounts only in the second set
+	Removed []*form3.Account `json:"removed"` // accounts only in the first set
+	Changed []*AccountChange `json:"changed"`
+}
+
+// HasDrift reports whether the two sets of accounts differ.
+func (r *Report) HasDrift() bool {
+	return len(r.Added) > 0 || len(r.Removed) > 0 || len(r.Changed) > 0
+}
+
+// Compare lists the accounts in from and to, matches them up and reports how
+// to differs from from. Organisation IDs and versions are not compared.
+func Compare(ctx context.Context, from, to Source, opts *Options) (*Report, error) {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.MatchBy == "" {
+		o.MatchBy = MatchByID
+	}
+	if o.MatchBy != MatchByID && o.MatchBy != MatchByBankAccount {
+		return nil, fmt.Errorf("unknown match %q", o.MatchBy)
+	}
+	ignore := make(map[string]bool)
+	for _, f := range o.IgnoreFields {
+		ignore[f] = true
+	}
+
+	fromAccounts, err := from.Accounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing accounts to compare from: %v", err)
+	}
+	toAccounts, err := to.Accounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing accounts to compare to: %v", err)
+	}
+	fromByKey, err := index(fromAccounts, o.MatchBy)
+	if err != nil {
+		return nil, err
+	}
+	toByKey, err := index(toAccounts, o.MatchBy)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		MatchBy: o.MatchBy,
+		Added:   []*form3.Account{},
+		Removed: []*form3.Account{},
+		Changed: []*AccountChange{},
+	}
+	for _, key := range sortedKeys(fromByKey) {
+		a := fromByKey[key]
+		b, ok := toByKey[key]
+		if !ok {
+			report.Removed = append(report.Removed, a)
+			continue
+		}
+		fields, err := diffAttributes(a.Attributes, b.Attributes, ignore, o.IgnoreUnset)
+		if err != nil {
+			return nil, fmt.Errorf("comparing %s: %v", key, err)
+		}
+		if len(fields) > 0 {
+			report.Changed = append(report.Changed, &AccountChange{Key: key, From: a, To: b, Fields: fields})
+		}
+	}
+	for _, key := range sortedKeys(toByKey) {
+		if _, ok := fromByKey[key]; !ok {
+			report.Added = append(report.Added, toByKey[key])
+		}
+	}
+	return report, nil
+}
+
+// matchKey returns the key a is matched on.
+func matchKey(a *form3.Account, by MatchBy) (string, error) {
+	if by == MatchByID {
+		if a.ID == nil || *a.ID == "" {
+			return "", fmt.Errorf("account has no ID")
+		}
+		return *a.ID, nil
+	}
+	var bankID, accountNumber string
+	if a.Attributes != nil {
+		bankID, accountNumber = stringValue(a.Attributes.BankId), stringValue(a.Attributes.AccountNumber)
+	}
+	if bankID == "" || accountNumber == "" {
+		return "", fmt.Errorf("account %s has no bank ID and account number to match on", stringValue(a.ID))
+	}
+	return bankID + "/" + accountNumber, nil
+}
+
+func index(accounts []*form3.Account, by MatchBy) (map[string]*form3.Account, error) {
+	m := make(map[string]*form3.Account, len(accounts))
+	for _, a := range accounts {
+		key, err := matchKey(a, by)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m[key]; ok {
+			return nil, fmt.Errorf("several accounts match on %s", key)
+		}
+		m[key] = a
+	}
+	return m, nil
+}
+
+func sortedKeys(m map[string]*form3.Account) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// attributeFields names the attributes of an account, in declaration order.
+var attributeFields = func() []string {
+	var names []string
+	t := reflect.TypeOf(form3.AccountAttributes{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}()
+
+// Diff returns the attributes that differ between a and b, either of which
+// may be nil.
+func Diff(a, b *form3.AccountAttributes) ([]*FieldDiff, error) {
+	return diffAttributes(a, b, nil, false)
+}
+
+// diffAttributes returns the attributes that differ between a and b, other
+// than those ignored and, if ignoreUnset is true, those not set in b.
+func diffAttributes(a, b *form3.AccountAttributes, ignore map[string]bool, ignoreUnset bool) ([]*FieldDiff, error) {
+	fa, err := fields(a)
+	if err != nil {
+		return nil, err
+	}
+	fb, err := fields(b)
+	if err != nil {
+		return nil, err
+	}
+	var diffs []*FieldDiff
+	for _, name := range attributeFields {
+		if ignore[name] {
+			continue
+		}
+		va, vb := fa[name], fb[name]
+		if ignoreUnset && string(vb) == "null" {
+			continue
+		}
+		if !bytes.Equal(va, vb) {
+			diffs = append(diffs, &FieldDiff{Field: name, From: va, To: vb})
+		}
+	}
+	return diffs, nil
+}
+
+// fields returns the JSON encoded attributes, with unset attributes and
+// empty lists as null.
+func fields(attrs *form3.AccountAttributes) (map[string]json.RawMessage, error) {
+	m := make(map[string]json.RawMessage)
+	if attrs != nil {
+		data, err := json.Marshal(attrs)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range attributeFields {
+		if v := m[name]; len(v) == 0 || string(v) == "[]" {
+			m[name] = json.RawMessage("null")
+		}
+	}
+	return m, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
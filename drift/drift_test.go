@@ -0,0 +1,174 @@
+package drift
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"form3.tech/go-form3/form3"
+	"form3.tech/go-form3/snapshot"
+)
+
+func account(id, bankID, accountNumber string, names ...string) *form3.Account {
+	return &form3.Account{
+		ID:      form3.String(id),
+		Version: form3.Int(0),
+		Attributes: &form3.AccountAttributes{
+			Country:       form3.CountryGB.Ptr(),
+			BankId:        form3.String(bankID),
+			AccountNumber: form3.String(accountNumber),
+			Name:          names,
+		},
+	}
+}
+
+func accounts(accounts ...*form3.Account) Source {
+	return SourceFunc(func(ctx context.Context) ([]*form3.Account, error) {
+		return accounts, nil
+	})
+}
+
+func ids(accounts []*form3.Account) []string {
+	var ids []string
+	for _, a := range accounts {
+		ids = append(ids, *a.ID)
+	}
+	return ids
+}
+
+func TestUnit_Compare_MatchByID(t *testing.T) {
+	from := accounts(
+		account("1", "400300", "11111111", "Alice"),
+		account("2", "400300", "22222222", "Bob"),
+		account("3", "400300", "33333333", "Carol"),
+	)
+	changed := account("2", "400300", "22222222", "Robert")
+	changed.Version = form3.Int(4)
+	changed.OrganisationId = form3.String("other")
+	to := accounts(
+		account("1", "400300", "11111111", "Alice"),
+		changed,
+		account("4", "400300", "44444444", "Dave"),
+	)
+
+	report, err := Compare(context.Background(), from, to, nil)
+	if err != nil {
+		t.Fatalf("Compare returned error: %v", err)
+	}
+	if got, want := ids(report.Added), []string{"4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Added = %v, want %v", got, want)
+	}
+	if got, want := ids(report.Removed), []string{"3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Removed = %v, want %v", got, want)
+	}
+	if len(report.Changed) != 1 {
+		t.Fatalf("Changed %d accounts, want 1", len(report.Changed))
+	}
+	want := []*FieldDiff{{Field: "name", From: json.RawMessage(`["Bob"]`), To: json.RawMessage(`["Robert"]`)}}
+	if got := report.Changed[0].Fields; !reflect.DeepEqual(got, want) {
+		t.Errorf("Changed fields = %+v, want %+v", got[0], want[0])
+	}
+	if !report.HasDrift() {
+		t.Errorf("HasDrift = false, want true")
+	}
+}
+
+func TestUnit_Compare_MatchByBankAccount(t *testing.T) {
+	from := accounts(account("1", "400300", "11111111", "Alice"))
+	to := accounts(account("a", "400300", "11111111", "Alice"))
+
+	report, err := Compare(context.Background(), from, to, &Options{MatchBy: MatchByBankAccount})
+	if err != nil {
+		t.Fatalf("Compare returned error: %v", err)
+	}
+	if report.HasDrift() {
+		t.Errorf("Compare reported drift between accounts with different IDs: %+v", report)
+	}
+}
+
+func TestUnit_Compare_IgnoreFields(t *testing.T) {
+	a := account("1", "400300", "11111111", "Alice")
+	b := account("1", "400300", "11111111", "Alice")
+	b.Attributes.Status = form3.StatusConfirmed.Ptr()
+	b.Attributes.AlternativeNames = []string{}
+
+	report, err := Compare(context.Background(), accounts(a), accounts(b), &Options{IgnoreFields: []string{"status"}})
+	if err != nil {
+		t.Fatalf("Compare returned error: %v", err)
+	}
+	if report.HasDrift() {
+		t.Errorf("Compare reported drift in ignored or empty fields: %+v", report.Changed[0].Fields)
+	}
+}
+
+func TestUnit_Compare_IgnoreUnset(t *testing.T) {
+	a := account("1", "400300", "11111111", "Alice")
+	a.Attributes.IBAN = form3.String("GB11NWBK40030011111111")
+	b := &form3.Account{ID: form3.String("1"), Attributes: &form3.AccountAttributes{Name: []string{"Alicia"}}}
+
+	report, err := Compare(context.Background(), accounts(a), accounts(b), &Options{IgnoreUnset: true})
+	if err != nil {
+		t.Fatalf("Compare returned error: %v", err)
+	}
+	if len(report.Changed) != 1 || len(report.Changed[0].Fields) != 1 || report.Changed[0].Fields[0].Field != "name" {
+		t.Errorf("Compare reported %+v, want only name changed", report.Changed)
+	}
+}
+
+func TestUnit_Diff(t *testing.T) {
+	diffs, err := Diff(nil, &form3.AccountAttributes{Country: form3.CountryGB.Ptr(), Name: []string{"Alice"}})
+	if err != nil {
+		t.Fatalf("Diff returned error: %v", err)
+	}
+	want := []*FieldDiff{
+		{Field: "country", From: json.RawMessage("null"), To: json.RawMessage(`"GB"`)},
+		{Field: "name", From: json.RawMessage("null"), To: json.RawMessage(`["Alice"]`)},
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("Diff returned %d differences, want %d", len(diffs), len(want))
+	}
+}
+
+func TestUnit_Compare_DuplicateKey(t *testing.T) {
+	from := accounts(account("1", "400300", "11111111"), account("2", "400300", "11111111"))
+
+	_, err := Compare(context.Background(), from, accounts(), &Options{MatchBy: MatchByBankAccount})
+	if err == nil {
+		t.Errorf("Expected error comparing accounts with the same bank account")
+	}
+}
+
+func TestUnit_Compare_SourceError(t *testing.T) {
+	failing := SourceFunc(func(ctx context.Context) ([]*form3.Account, error) {
+		return nil, errors.New("unavailable")
+	})
+
+	if _, err := Compare(context.Background(), accounts(), failing, nil); err == nil {
+		t.Errorf("Expected error when a source fails")
+	}
+}
+
+func TestUnit_FromService_FromSnapshot(t *testing.T) {
+	listed := []*form3.Account{account("1", "400300", "11111111", "Alice")}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&form3.AccountDetailsListResponse{Data: listed})
+	}))
+	defer server.Close()
+	client := form3.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/v1/")
+
+	snap := &snapshot.Snapshot{Accounts: []*form3.Account{account("1", "400300", "11111111", "Alicia")}}
+
+	report, err := Compare(context.Background(), FromService(client.Accounts, nil), FromSnapshot(snap), nil)
+	if err != nil {
+		t.Fatalf("Compare returned error: %v", err)
+	}
+	if len(report.Changed) != 1 || report.Changed[0].Fields[0].Field != "name" {
+		t.Errorf("Compare reported %+v, want name changed", report)
+	}
+}
@@ -0,0 +1,60 @@
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"form3.tech/go-form3/form3"
+)
+
+// WriteText writes a human readable summary of r to w, one line per added
+// (+), removed (-) or changed (~) account, followed by the changed fields.
+func (r *Report) WriteText(w io.Writer) error {
+	if !r.HasDrift() {
+		_, err := fmt.Fprintln(w, "No differences.")
+		return err
+	}
+	for _, a := range r.Added {
+		if _, err := fmt.Fprintf(w, "+ %s\n", describe(a)); err != nil {
+			return err
+		}
+	}
+	for _, a := range r.Removed {
+		if _, err := fmt.Fprintf(w, "- %s\n", describe(a)); err != nil {
+			return err
+		}
+	}
+	for _, c := range r.Changed {
+		if _, err := fmt.Fprintf(w, "~ %s\n", describe(c.From)); err != nil {
+			return err
+		}
+		for _, f := range c.Fields {
+			if _, err := fmt.Fprintf(w, "    %s: %s -> %s\n", f.Field, f.From, f.To); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d added, %d removed, %d changed.\n", len(r.Added), len(r.Removed), len(r.Changed))
+	return err
+}
+
+// WriteJSON writes r to w as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// describe identifies a in text output.
+func describe(a *form3.Account) string {
+	s := "account " + stringValue(a.ID)
+	if a.Attributes != nil && a.Attributes.AccountNumber != nil {
+		country := ""
+		if a.Attributes.Country != nil {
+			country = string(*a.Attributes.Country) + " "
+		}
+		s += fmt.Sprintf(" (%s%s %s)", country, stringValue(a.Attributes.BankId), *a.Attributes.AccountNumber)
+	}
+	return s
+}
@@ -0,0 +1,65 @@
+package drift
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestUnit_Report_WriteText(t *testing.T) {
+	from := accounts(account("1", "400300", "11111111", "Bob"), account("2", "400300", "22222222"))
+	to := accounts(account("1", "400300", "11111111", "Robert"), account("3", "400300", "33333333"))
+	report, err := Compare(context.Background(), from, to, nil)
+	if err != nil {
+		t.Fatalf("Compare returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText returned error: %v", err)
+	}
+	want := `+ account 3 (GB 400300 33333333)
+- account 2 (GB 400300 22222222)
+~ account 1 (GB 400300 11111111)
+    name: ["Bob"] -> ["Robert"]
+1 added, 1 removed, 1 changed.
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteText wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnit_Report_WriteText_NoDrift(t *testing.T) {
+	var buf bytes.Buffer
+	if err := new(Report).WriteText(&buf); err != nil {
+		t.Fatalf("WriteText returned error: %v", err)
+	}
+	if got, want := buf.String(), "No differences.\n"; got != want {
+		t.Errorf("WriteText wrote %q, want %q", got, want)
+	}
+}
+
+func TestUnit_Report_WriteJSON(t *testing.T) {
+	report, err := Compare(context.Background(), accounts(account("1", "400300", "11111111", "Bob")), accounts(account("1", "400300", "11111111", "Robert")), nil)
+	if err != nil {
+		t.Fatalf("Compare returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+	got := new(Report)
+	if err := json.Unmarshal(buf.Bytes(), got); err != nil {
+		t.Fatalf("Decoding JSON output: %v", err)
+	}
+	if got.MatchBy != MatchByID || len(got.Changed) != 1 {
+		t.Fatalf("WriteJSON wrote %s", buf.Bytes())
+	}
+	var to bytes.Buffer
+	json.Compact(&to, got.Changed[0].Fields[0].To)
+	if to.String() != `["Robert"]` {
+		t.Errorf("WriteJSON wrote %s", buf.Bytes())
+	}
+}
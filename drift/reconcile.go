@@ -0,0 +1,107 @@
+package drift
+
+import (
+	"context"
+	"encoding/json"
+
+	"form3.tech/go-form3/form3"
+)
+
+// A ReconcileResult is the outcome of Reconcile.
+type ReconcileResult struct {
+	Created []string // IDs of the accounts created
+	Updated []string // IDs of the accounts updated
+	Deleted []string // IDs of the accounts deleted
+
+	// Accounts that could not be reconciled, by ID.
+	Failed map[string]error
+}
+
+// Reconcile applies r to the accounts of s, which hold the first set of
+// accounts compared, so that they match the second: added accounts are
+// created, removed accounts are deleted and changed accounts are updated
+// with the attributes of their match that differ. Only the differences in
+// the report are applied, so attributes that Compare was told to ignore keep
+// their values.
+//
+// Added accounts keep their IDs when matched by ID, and are given new IDs
+// otherwise; they are created in the organisation of s's client. Attributes
+// removed from a changed account cannot be cleared by an update, and are
+// left as they are. Accounts that fail to be reconciled are reported in the
+// result; an error is returned only if ctx is done.
+func Reconcile(ctx context.Context, s *form3.AccountsService, r *Report) (*ReconcileResult, error) {
+	result := &ReconcileResult{Failed: make(map[string]error)}
+
+	for _, a := range r.Added {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		account := &form3.Account{Attributes: a.Attributes}
+		if r.MatchBy == MatchByID {
+			account.ID = a.ID
+		}
+		created, _, err := s.Create(ctx, account)
+		if err != nil {
+			result.Failed[stringValue(a.ID)] = err
+			continue
+		}
+		result.Created = append(result.Created, stringValue(created.ID))
+	}
+
+	for _, c := range r.Changed {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		attrs, err := changedAttributes(c)
+		if err != nil {
+			result.Failed[stringValue(c.From.ID)] = err
+			continue
+		}
+		account := &form3.Account{ID: c.From.ID, Version: c.From.Version, Attributes: attrs}
+		if _, _, err := s.Update(ctx, account); err != nil {
+			result.Failed[stringValue(c.From.ID)] = err
+			continue
+		}
+		result.Updated = append(result.Updated, stringValue(c.From.ID))
+	}
+
+	for _, a := range r.Removed {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		version := 0
+		if a.Version != nil {
+			version = *a.Version
+		}
+		if _, err := s.Delete(ctx, stringValue(a.ID), version); err != nil {
+			result.Failed[stringValue(a.ID)] = err
+			continue
+		}
+		result.Deleted = append(result.Deleted, stringValue(a.ID))
+	}
+	return result, nil
+}
+
+// changedAttributes returns the attributes of c.From with those that differ
+// set to their values in c.To. Attributes unset in c.To are left as they
+// are, as an update cannot clear them.
+func changedAttributes(c *AccountChange) (*form3.AccountAttributes, error) {
+	fields, err := fields(c.From.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range c.Fields {
+		if string(d.To) != "null" {
+			fields[d.Field] = d.To
+		}
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	attrs := new(form3.AccountAttributes)
+	if err := json.Unmarshal(data, attrs); err != nil {
+		return nil, err
+	}
+	return attrs, nil
+}
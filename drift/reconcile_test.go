@@ -0,0 +1,121 @@
+package drift
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"form3.tech/go-form3/form3"
+)
+
+func TestUnit_Reconcile(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		body := new(form3.AccountCreation)
+		json.NewDecoder(r.Body).Decode(body)
+		mu.Lock()
+		requests = append(requests, r.Method+" "+*body.Data.ID+" "+stringValue(body.Data.OrganisationId))
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(&form3.AccountCreationResponse{Data: body.Data})
+	})
+	mux.HandleFunc("/v1/organisation/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path[len("/v1/organisation/accounts/"):]+"?"+r.URL.RawQuery)
+		mu.Unlock()
+		switch r.Method {
+		case "PATCH":
+			body := new(form3.AccountUpdate)
+			json.NewDecoder(r.Body).Decode(body)
+			if got := body.Data.Attributes.Name; !reflect.DeepEqual(got, []string{"Robert"}) {
+				t.Errorf("Update sent name %v, want [Robert]", got)
+			}
+			json.NewEncoder(w).Encode(&form3.AccountDetailsResponse{Data: body.Data})
+		case "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := form3.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/v1/")
+
+	removed := account("2", "400300", "22222222")
+	removed.Version = form3.Int(2)
+	from := accounts(account("1", "400300", "11111111", "Bob"), removed)
+	added := account("3", "400300", "33333333")
+	added.OrganisationId = form3.String("eb0bd6f5-c3f5-44b2-b677-acd23cdde73c")
+	to := accounts(account("1", "400300", "11111111", "Robert"), added)
+	report, err := Compare(context.Background(), from, to, nil)
+	if err != nil {
+		t.Fatalf("Compare returned error: %v", err)
+	}
+
+	result, err := Reconcile(context.Background(), client.Accounts, report)
+	if err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+	if len(result.Failed) != 0 {
+		t.Errorf("Reconcile failed for %v", result.Failed)
+	}
+	if !reflect.DeepEqual(result.Created, []string{"3"}) || !reflect.DeepEqual(result.Updated, []string{"1"}) || !reflect.DeepEqual(result.Deleted, []string{"2"}) {
+		t.Errorf("Reconcile returned %+v", result)
+	}
+
+	// The added account is created in the client's organisation, not that
+	// of the second set.
+	sort.Strings(requests)
+	want := []string{"DELETE 2?version=2", "PATCH 1?", "POST 3 "}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("Reconcile sent %v, want %v", requests, want)
+	}
+}
+
+func TestUnit_Reconcile_IgnoredFields(t *testing.T) {
+	var sent *form3.AccountAttributes
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := new(form3.AccountUpdate)
+		json.NewDecoder(r.Body).Decode(body)
+		sent = body.Data.Attributes
+		json.NewEncoder(w).Encode(&form3.AccountDetailsResponse{Data: body.Data})
+	}))
+	defer server.Close()
+	client := form3.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/v1/")
+
+	staging := account("1", "400300", "11111111", "Bob")
+	staging.Attributes.IBAN = form3.String("GB11NWBK40030011111111")
+	staging.Attributes.Status = form3.StatusPending.Ptr()
+	production := account("1", "400300", "11111111", "Robert")
+	production.Attributes.IBAN = form3.String("GB22NWBK40030011111111")
+	production.Attributes.Status = form3.StatusConfirmed.Ptr()
+	report, err := Compare(context.Background(), accounts(staging), accounts(production), &Options{IgnoreFields: []string{"iban", "status"}})
+	if err != nil {
+		t.Fatalf("Compare returned error: %v", err)
+	}
+
+	result, err := Reconcile(context.Background(), client.Accounts, report)
+	if err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Updated, []string{"1"}) {
+		t.Fatalf("Reconcile returned %+v, want account 1 updated", result)
+	}
+	if !reflect.DeepEqual(sent.Name, []string{"Robert"}) {
+		t.Errorf("Update sent name %v, want [Robert]", sent.Name)
+	}
+	if got := stringValue(sent.IBAN); got != "GB11NWBK40030011111111" {
+		t.Errorf("Update sent ignored IBAN %v, want it unchanged", got)
+	}
+	if sent.Status == nil || *sent.Status != form3.StatusPending {
+		t.Errorf("Update sent ignored status %v, want it unchanged", sent.Status)
+	}
+}
@@ -2,6 +2,7 @@ package form3
 
 import (
 	"context"
+	"errors"
 	"fmt"
 )
 
@@ -11,6 +12,9 @@ import (
 // Form3 API docs: https://api-docs.form3.tech/api.html#organisation-accounts
 type AccountsService service
 
+// accountsType is the JSON:API resource type of an Account.
+const accountsType = "accounts"
+
 // An Account represents a bank account that is registered with Form3.
 // It is used to validate and allocate inbound payments.
 type Account struct {
@@ -19,50 +23,45 @@ type Account struct {
 	OrganisationId *string            `json:"organisation_id"`
 	Version        *int               `json:"version,omitempty"`
 	Attributes     *AccountAttributes `json:"attributes"`
+	Relationships  Relationships      `json:"relationships,omitempty"`
+
+	// Accounts referred to by the master_account relationship, if they were
+	// included in the response.
+	MasterAccounts []*Account `json:"-" jsonapi:"master_account"`
 }
 
 // Form3 API docs: https://api-docs.form3.tech/api.html#organisation-accounts-resource
 type AccountAttributes struct {
-	Country                     *string  `json:"country"`                                  // ISO 3166-1 code used to identify the domicile of the account, e.g. 'GB', 'FR'
-	BaseCurrency                *string  `json:"base_currency,omitempty"`                  // ISO 4217 code used to identify the base currency of the account, e.g. 'GBP', 'EUR'
-	AccountNumber               *string  `json:"account_number,omitempty"`                 // Local country bank identifier. Format depends on the country. Required for most countries.
-	BankId                      *string  `json:"bank_id,omitempty"`                        // Identifies the type of bank ID being used, see here for allowed value for each country. Required value depends on country attribute.
-	BankIdCode                  *string  `json:"bank_id_code,omitempty"`                   // Account number. A unique account number will automatically be generated if not provided. If provided, the account number is not validated.
-	BIC                         *string  `json:"bic,omitempty"`                            // SWIFT BIC in either 8 or 11 character format e.g. 'NWBKGB22'
-	IBAN                        *string  `json:"iban,omitempty"`                           // IBAN of the account. Will be calculated from other fields if not supplied.
-	CustomerId                  *string  `json:"customer_id,omitempty"`                    // A free-format reference that can be used to link this account to an external system
-	Name                        []string `json:"name"`                                     // Name of the account holder, up to four lines possible.
-	AlternativeNames            []string `json:"alternative_names,omitempty"`              // Alternative primary account names, only used for UK Confirmation of Payee
-	AccountClassification       *string  `json:"account_classification,omitempty"`         // Classification of account, only used for Confirmation of Payee (CoP)
-	JointAccount                *bool    `json:"joint_account,omitempty"`                  // Flag to indicate if the account is a joint account, only used for Confirmation of Payee (CoP)
-	AccountMatchingOptOut       *bool    `json:"account_matching_opt_out,omitempty"`       // Flag to indicate if the account has opted out of account matching, only used for Confirmation of Payee
-	SecondaryIdentification     *string  `json:"secondary_identification,omitempty"`       // Additional information to identify the account and account holder, only used for Confirmation of Payee (CoP)
-	Switched                    *bool    `json:"switched,omitempty"`                       // Flag to indicate if the account has been switched away from this organisation, only used for Confirmation of Payee (CoP)
-	Status                      *string  `json:"status,omitempty"`                         // Status of the account. Inferred from the status field of the newest Account Event resource associated with the account. Always confirmed for older accounts where no Account Event resources are
-	Title                       *string  `json:"title,omitempty"`                          // [Deprecated] The account holder's title, e.g. Ms, Dr, Mr. Only used for UK Confirmation of Payee (CoP). Superseded by name.
-	FirstName                   *string  `json:"first_name,omitempty"`                     // [Deprecated] The account holder's first name, only used for UK Confirmation of Payee (CoP). Superseded by name.
-	BankAccountName             *string  `json:"bank_account_name,omitempty"`              // [Deprecated] Primary account name, only used for UK Confirmation of Payee (CoP). Superseded by name.
-	AlternativeBankAccountNames *string  `json:"alternative_bank_account_names,omitempty"` // [Deprecated] Alternative primary account names, only used for UK Confirmation of Payee. Superseded by alternative_names.
-}
-
-type AccountDetailsResponse struct {
-	Data  *Account `json:"data"`
-	Links *Links   `json:"links"`
-}
-
-type AccountDetailsListResponse struct {
-	Data  []*Account `json:"data"`
-	Links *Links     `json:"links"`
-}
-
-type AccountCreation struct {
-	Data *Account `json:"data"`
+	Country                     *Country               `json:"country"`                                  // ISO 3166-1 code used to identify the domicile of the account, e.g. 'GB', 'FR'
+	BaseCurrency                *Currency              `json:"base_currency,omitempty"`                  // ISO 4217 code used to identify the base currency of the account, e.g. 'GBP', 'EUR'
+	AccountNumber               *string                `json:"account_number,omitempty"`                 // Local country bank identifier. Format depends on the country. Required for most countries.
+	BankId                      *string                `json:"bank_id,omitempty"`                        // Identifies the type of bank ID being used, see here for allowed value for each country. Required value depends on country attribute.
+	BankIdCode                  *BankIdCode            `json:"bank_id_code,omitempty"`                   // Account number. A unique account number will automatically be generated if not provided. If provided, the account number is not validated.
+	BIC                         *string                `json:"bic,omitempty"`                            // SWIFT BIC in either 8 or 11 character format e.g. 'NWBKGB22'
+	IBAN                        *string                `json:"iban,omitempty"`                           // IBAN of the account. Will be calculated from other fields if not supplied.
+	CustomerId                  *string                `json:"customer_id,omitempty"`                    // A free-format reference that can be used to link this account to an external system
+	Name                        []string               `json:"name"`                                     // Name of the account holder, up to four lines possible.
+	AlternativeNames            []string               `json:"alternative_names,omitempty"`              // Alternative primary account names, only used for UK Confirmation of Payee
+	AccountClassification       *AccountClassification `json:"account_classification,omitempty"`         // Classification of account, only used for Confirmation of Payee (CoP)
+	JointAccount                *bool                  `json:"joint_account,omitempty"`                  // Flag to indicate if the account is a joint account, only used for Confirmation of Payee (CoP)
+	AccountMatchingOptOut       *bool                  `json:"account_matching_opt_out,omitempty"`       // Flag to indicate if the account has opted out of account matching, only used for Confirmation of Payee
+	SecondaryIdentification     *string                `json:"secondary_identification,omitempty"`       // Additional information to identify the account and account holder, only used for Confirmation of Payee (CoP)
+	Switched                    *bool                  `json:"switched,omitempty"`                       // Flag to indicate if the account has been switched away from this organisation, only used for Confirmation of Payee (CoP)
+	Status                      *AccountStatus         `json:"status,omitempty"`                         // Status of the account. Inferred from the status field of the newest Account Event resource associated with the account. Always confirmed for older accounts where no Account Event resources are
+	Title                       *string                `json:"title,omitempty"`                          // [Deprecated] The account holder's title, e.g. Ms, Dr, Mr. Only used for UK Confirmation of Payee (CoP). Superseded by name.
+	FirstName                   *string                `json:"first_name,omitempty"`                     // [Deprecated] The account holder's first name, only used for UK Confirmation of Payee (CoP). Superseded by name.
+	BankAccountName             *string                `json:"bank_account_name,omitempty"`              // [Deprecated] Primary account name, only used for UK Confirmation of Payee (CoP). Superseded by name.
+	AlternativeBankAccountNames *string                `json:"alternative_bank_account_names,omitempty"` // [Deprecated] Alternative primary account names, only used for UK Confirmation of Payee. Superseded by alternative_names.
 }
 
-type AccountCreationResponse struct {
-	Data  *Account `json:"data"`
-	Links *Links   `json:"links"`
-}
+// Envelopes of requests and responses of the accounts endpoints.
+type (
+	AccountDetailsResponse     = Document[Account]
+	AccountDetailsListResponse = ListDocument[Account]
+	AccountCreation            = Document[Account]
+	AccountCreationResponse    = Document[Account]
+	AccountUpdate              = Document[Account]
+)
 
 // Register an existing bank account with Form3 or create a new one.
 // The country attribute must be specified as a minimum. Depending on the country,
@@ -72,9 +71,18 @@ type AccountCreationResponse struct {
 // - If an account number is provided but the IBAN is empty, Form3 generates an IBAN if supported by the country.
 // - If only an IBAN is provided, the account number will be left empty.
 // Note that a given bank_id and bic need to be registered with Form3 and connected to your organisation ID.
+// If the account has no ID, type or organisation ID they are filled in from the client's IDGenerator,
+// the accounts resource type and the client's OrganisationID respectively. If the client normalises
+// deprecated attributes, they are replaced as described by NormaliseAccountAttributes and any warnings
+// are returned in the Response. The account passed in is not modified.
 // Form3 API docs: https://api-docs.form3.tech/api.html#organisation-accounts-create
 func (s *AccountsService) Create(ctx context.Context, account *Account) (*Account, *Response, error) {
 	u := "organisation/accounts"
+	account, err := s.prepareNew(account)
+	if err != nil {
+		return nil, nil, err
+	}
+	account, warnings := s.normaliseAccount(account)
 	payload := &AccountCreation{Data: account}
 	req, err := s.client.NewRequest("POST", u, payload)
 	if err != nil {
@@ -84,7 +92,10 @@ func (s *AccountsService) Create(ctx context.Context, account *Account) (*Accoun
 	req.Header.Set("Accept", jsonApiMediaType)
 
 	m := &AccountCreationResponse{}
-	resp, err := s.client.Do(ctx, req, m)
+	resp, err := s.client.Do(withOperation(ctx, "accounts.create"), req, m)
+	if resp != nil {
+		resp.Warnings = warnings
+	}
 	if err != nil {
 		return nil, resp, err
 	}
@@ -92,6 +103,36 @@ func (s *AccountsService) Create(ctx context.Context, account *Account) (*Accoun
 	return m.Data, resp, nil
 }
 
+// prepareNew returns a copy of account with any missing ID, type and
+// organisation ID filled in.
+func (s *AccountsService) prepareNew(account *Account) (*Account, error) {
+	a := new(Account)
+	if account != nil {
+		*a = *account
+	}
+
+	if a.ID == nil || *a.ID == "" {
+		id, err := s.client.newID()
+		if err != nil {
+			return nil, fmt.Errorf("generating account ID: %v", err)
+		}
+		a.ID = String(id)
+	}
+	if a.Type == nil || *a.Type == "" {
+		a.Type = String(accountsType)
+	}
+	if (a.OrganisationId == nil || *a.OrganisationId == "") && s.client.OrganisationID != "" {
+		a.OrganisationId = String(s.client.OrganisationID)
+	}
+	if err := s.client.checkOrganisation(a); err != nil {
+		return nil, err
+	}
+	if err := checkEnums(a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
 // Get a single account using the account ID.
 // Form3 API docs: https://api-docs.form3.tech/api.html#organisation-accounts-fetch
 func (s *AccountsService) Fetch(ctx context.Context, id string) (*AccountDetailsResponse, *Response, error) {
@@ -103,7 +144,7 @@ func (s *AccountsService) Fetch(ctx context.Context, id string) (*AccountDetails
 	}
 
 	accountDetails := new(AccountDetailsResponse)
-	resp, err := s.client.Do(ctx, req, accountDetails)
+	resp, err := s.client.Do(withOperation(ctx, "accounts.fetch"), req, accountDetails)
 	if err != nil {
 		return nil, resp, err
 	}
@@ -111,10 +152,54 @@ func (s *AccountsService) Fetch(ctx context.Context, id string) (*AccountDetails
 	return accountDetails, resp, nil
 }
 
-// List accounts with the ability to page.
+// AccountListOptions specifies the optional parameters to
+// AccountsService.ListWithOptions.
+type AccountListOptions struct {
+	ListOptions
+
+	// Restricts the accounts listed to those matching the filter.
+	Filter *AccountFilter `url:"filter,omitempty"`
+
+	// Restricts the attributes returned to those listed.
+	Fields Fields[AccountField] `url:"fields[accounts],omitempty"`
+
+	// Includes the resources related to the accounts listed, resolved into
+	// the accounts' relation fields.
+	Include Include[AccountRelationship] `url:"include,omitempty"`
+
+	// Orders the accounts listed.
+	Sort Sort[AccountField] `url:"sort,omitempty"`
+}
+
+// AccountFilter restricts a list of accounts to those matching every
+// attribute set.
+// Form3 API docs: https://api-docs.form3.tech/api.html#organisation-accounts-list
+type AccountFilter struct {
+	OrganisationID string     `url:"organisation_id,omitempty"`
+	BankIdCode     BankIdCode `url:"bank_id_code,omitempty"`
+	BankID         string     `url:"bank_id,omitempty"`
+	AccountNumber  string     `url:"account_number,omitempty"`
+	IBAN           string     `url:"iban,omitempty"`
+	CustomerID     string     `url:"customer_id,omitempty"`
+	Country        Country    `url:"country,omitempty"`
+}
+
+// List accounts with the ability to page. See ListWithOptions to filter,
+// sort or select the fields listed.
 // Form3 API docs: https://api-docs.form3.tech/api.html#organisation-accounts-list
 func (s *AccountsService) List(ctx context.Context, options *ListOptions) (*AccountDetailsListResponse, *Response, error) {
-	u, err := addOptions("organisation/accounts", options)
+	var opts *AccountListOptions
+	if options != nil {
+		opts = &AccountListOptions{ListOptions: *options}
+	}
+	return s.ListWithOptions(ctx, opts)
+}
+
+// ListWithOptions lists accounts with the ability to page, filter, sort and
+// select the fields listed.
+// Form3 API docs: https://api-docs.form3.tech/api.html#organisation-accounts-list
+func (s *AccountsService) ListWithOptions(ctx context.Context, options *AccountListOptions) (*AccountDetailsListResponse, *Response, error) {
+	u, err := addOptions("organisation/accounts", s.scopeListOptions(options))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -125,7 +210,7 @@ func (s *AccountsService) List(ctx context.Context, options *ListOptions) (*Acco
 	}
 
 	accountDetailsList := new(AccountDetailsListResponse)
-	resp, err := s.client.Do(ctx, req, accountDetailsList)
+	resp, err := s.client.Do(withOperation(ctx, "accounts.list"), req, accountDetailsList)
 	if err != nil {
 		return nil, resp, err
 	}
@@ -133,6 +218,46 @@ func (s *AccountsService) List(ctx context.Context, options *ListOptions) (*Acco
 	return accountDetailsList, resp, nil
 }
 
+// Update an existing account. The account must have its ID and current version set; only the
+// attributes provided are changed. Deprecated attributes are normalised as for Create.
+// Form3 API docs: https://api-docs.form3.tech/api.html#organisation-accounts-patch
+func (s *AccountsService) Update(ctx context.Context, account *Account) (*Account, *Response, error) {
+	if account == nil || account.ID == nil || *account.ID == "" {
+		return nil, nil, errors.New("account to update must have an ID")
+	}
+	if account.Version == nil {
+		return nil, nil, errors.New("account to update must have a version")
+	}
+	u := fmt.Sprintf("organisation/accounts/%v", *account.ID)
+
+	a := *account
+	if a.Type == nil || *a.Type == "" {
+		a.Type = String(accountsType)
+	}
+	if err := s.client.checkOrganisation(&a); err != nil {
+		return nil, nil, err
+	}
+	if err := checkEnums(&a); err != nil {
+		return nil, nil, err
+	}
+	payload, warnings := s.normaliseAccount(&a)
+	req, err := s.client.NewRequest("PATCH", u, &AccountUpdate{Data: payload})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accountDetails := new(AccountDetailsResponse)
+	resp, err := s.client.Do(withOperation(ctx, "accounts.update"), req, accountDetails)
+	if resp != nil {
+		resp.Warnings = warnings
+	}
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return accountDetails.Data, resp, nil
+}
+
 // Delete an account
 // Form3 API docs: https://api-docs.form3.tech/api.html#organisation-accounts-delete
 func (s *AccountsService) Delete(ctx context.Context, id string, version int) (*Response, error) {
@@ -143,5 +268,5 @@ func (s *AccountsService) Delete(ctx context.Context, id string, version int) (*
 		return nil, err
 	}
 
-	return s.client.Do(ctx, req, nil)
+	return s.client.Do(withOperation(ctx, "accounts.delete"), req, nil)
 }
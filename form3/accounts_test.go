@@ -2,6 +2,7 @@ package form3
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -44,8 +45,8 @@ func TestUnit_AccountsService_Create(t *testing.T) {
 		OrganisationId: String("eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"),
 		Version:        Int(7),
 		Attributes: &AccountAttributes{
-			Country:      String("GB"),
-			BaseCurrency: String("GBP"),
+			Country:      CountryGB.Ptr(),
+			BaseCurrency: CurrencyGBP.Ptr(),
 		},
 	}
 	if !reflect.DeepEqual(createdAccount, want) {
@@ -53,6 +54,75 @@ func TestUnit_AccountsService_Create(t *testing.T) {
 	}
 }
 
+func TestUnit_AccountsService_Create_Defaults(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	client.OrganisationID = "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"
+	client.IDGenerator = func() (string, error) { return "d97a4470-299f-11eb-adc1-0242ac120002", nil }
+
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+
+		got := new(AccountCreation)
+		json.NewDecoder(r.Body).Decode(got)
+		want := &AccountCreation{
+			Data: &Account{
+				ID:             String("d97a4470-299f-11eb-adc1-0242ac120002"),
+				Type:           String("accounts"),
+				OrganisationId: String("eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"),
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Request body = %+v, want %+v", got.Data, want.Data)
+		}
+
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+
+	account := &Account{}
+	_, _, err := client.Accounts.Create(context.Background(), account)
+	if err != nil {
+		t.Errorf("Create returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(account, &Account{}) {
+		t.Errorf("Create modified the account passed in: %+v", account)
+	}
+}
+
+func TestUnit_AccountsService_Create_KeepsProvidedValues(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	client.OrganisationID = "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"
+	client.IDGenerator = func() (string, error) {
+		t.Error("IDGenerator called for an account with an ID")
+		return "", nil
+	}
+
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		got := new(AccountCreation)
+		json.NewDecoder(r.Body).Decode(got)
+		if got, want := *got.Data.ID, "1d50df61-db36-483c-9975-41141d691be1"; got != want {
+			t.Errorf("Request ID = %v, want %v", got, want)
+		}
+		if got, want := *got.Data.OrganisationId, "58d8a2c8-29ca-11eb-adc1-0242ac120002"; got != want {
+			t.Errorf("Request organisation_id = %v, want %v", got, want)
+		}
+
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+
+	_, _, err := client.Accounts.Create(context.Background(), &Account{
+		ID:             String("1d50df61-db36-483c-9975-41141d691be1"),
+		OrganisationId: String("58d8a2c8-29ca-11eb-adc1-0242ac120002"),
+	})
+	if err != nil {
+		t.Errorf("Create returned error: %v", err)
+	}
+}
+
 func TestUnit_AccountsService_Create_BadRequest(t *testing.T) {
 	client, mux, _, teardown := setupClientWithStubbedApi()
 	defer teardown()
@@ -114,8 +184,8 @@ func TestUnit_AccountsService_Fetch(t *testing.T) {
 			OrganisationId: String("eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"),
 			Version:        Int(7),
 			Attributes: &AccountAttributes{
-				Country:      String("GB"),
-				BaseCurrency: String("GBP"),
+				Country:      CountryGB.Ptr(),
+				BaseCurrency: CurrencyGBP.Ptr(),
 			},
 		},
 	}
@@ -166,8 +236,8 @@ func TestUnit_AccountsService_List(t *testing.T) {
 				OrganisationId: String("eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"),
 				Version:        Int(7),
 				Attributes: &AccountAttributes{
-					Country:      String("GB"),
-					BaseCurrency: String("GBP"),
+					Country:      CountryGB.Ptr(),
+					BaseCurrency: CurrencyGBP.Ptr(),
 				},
 			},
 		},
@@ -177,6 +247,112 @@ func TestUnit_AccountsService_List(t *testing.T) {
 	}
 }
 
+func TestUnit_AccountsService_List_SparseFieldsets(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testFormValues(t, r, values{
+			"fields[accounts]": "country,iban",
+			"include":          "master_account",
+			"sort":             "country,-iban",
+		})
+		fmt.Fprint(w, `
+		{
+			"data": [
+				{"id": "1", "type": "accounts", "attributes": {"iban": "GB11NWBK40030041426819"}},
+				{"id": "2", "type": "accounts"}
+			]
+		}`)
+	})
+
+	opts := &AccountListOptions{
+		Fields:  Fields[AccountField]{AccountFieldCountry, AccountFieldIBAN},
+		Include: Include[AccountRelationship]{AccountRelationshipMasterAccount},
+		Sort:    Sort[AccountField]{Asc(AccountFieldCountry), Desc(AccountFieldIBAN)},
+	}
+	list, _, err := client.Accounts.ListWithOptions(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Accounts.List returned error: %v", err)
+	}
+	if len(list.Data) != 2 {
+		t.Fatalf("Accounts.List returned %d accounts, want 2", len(list.Data))
+	}
+	if attrs := list.Data[0].Attributes; attrs.Country != nil || *attrs.IBAN != "GB11NWBK40030041426819" {
+		t.Errorf("Accounts.List returned attributes %+v, want only IBAN", attrs)
+	}
+	if list.Data[1].Attributes != nil {
+		t.Errorf("Accounts.List returned attributes %+v, want none", list.Data[1].Attributes)
+	}
+}
+
+func TestUnit_AccountsService_Update(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	mux.HandleFunc("/organisation/accounts/d97a4470-299f-11eb-adc1-0242ac120002", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PATCH")
+		testHeader(t, r, "Accept", jsonApiMediaType)
+
+		got := new(AccountUpdate)
+		json.NewDecoder(r.Body).Decode(got)
+		want := &AccountUpdate{
+			Data: &Account{
+				ID:         String("d97a4470-299f-11eb-adc1-0242ac120002"),
+				Type:       String("accounts"),
+				Version:    Int(7),
+				Attributes: &AccountAttributes{CustomerId: String("12345")},
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Request body = %+v, want %+v", got.Data, want.Data)
+		}
+
+		fmt.Fprint(w, `
+		{
+			"data": {
+				"id": "d97a4470-299f-11eb-adc1-0242ac120002",
+				"type": "accounts",
+				"version": 8,
+				"attributes": {
+					"customer_id": "12345"
+				}
+			}
+		}`)
+	})
+
+	account, _, err := client.Accounts.Update(context.Background(), &Account{
+		ID:         String("d97a4470-299f-11eb-adc1-0242ac120002"),
+		Version:    Int(7),
+		Attributes: &AccountAttributes{CustomerId: String("12345")},
+	})
+	if err != nil {
+		t.Errorf("Accounts.Update returned error: %v", err)
+	}
+
+	want := &Account{
+		ID:         String("d97a4470-299f-11eb-adc1-0242ac120002"),
+		Type:       String("accounts"),
+		Version:    Int(8),
+		Attributes: &AccountAttributes{CustomerId: String("12345")},
+	}
+	if !reflect.DeepEqual(account, want) {
+		t.Errorf("Accounts.Update returned %+v, want %+v", account, want)
+	}
+}
+
+func TestUnit_AccountsService_Update_Invalid(t *testing.T) {
+	client := NewClient(nil)
+
+	if _, _, err := client.Accounts.Update(context.Background(), &Account{Version: Int(0)}); err == nil {
+		t.Error("Accounts.Update without ID did not return error")
+	}
+	if _, _, err := client.Accounts.Update(context.Background(), &Account{ID: String("d97a4470-299f-11eb-adc1-0242ac120002")}); err == nil {
+		t.Error("Accounts.Update without version did not return error")
+	}
+}
+
 func TestUnit_AccountsService_Delete(t *testing.T) {
 	client, mux, _, teardown := setupClientWithStubbedApi()
 	defer teardown()
@@ -203,10 +379,10 @@ func TestIntegration_AccountsService_Create(t *testing.T) {
 		Type:           String("accounts"),
 		OrganisationId: String("eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"),
 		Attributes: &AccountAttributes{
-			Country:      String("GB"),
-			BaseCurrency: String("GBP"),
+			Country:      CountryGB.Ptr(),
+			BaseCurrency: CurrencyGBP.Ptr(),
 			BankId:       String("400300"),
-			BankIdCode:   String("GBDSC"),
+			BankIdCode:   BankIdCodeGBDSC.Ptr(),
 			BIC:          String("NWBKGB22"),
 		},
 	}
@@ -237,10 +413,10 @@ func TestIntegration_AccountsService_Create_Duplicate(t *testing.T) {
 		Type:           String("accounts"),
 		OrganisationId: String("eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"),
 		Attributes: &AccountAttributes{
-			Country:      String("GB"),
-			BaseCurrency: String("GBP"),
+			Country:      CountryGB.Ptr(),
+			BaseCurrency: CurrencyGBP.Ptr(),
 			BankId:       String("400300"),
-			BankIdCode:   String("GBDSC"),
+			BankIdCode:   BankIdCodeGBDSC.Ptr(),
 			BIC:          String("NWBKGB22"),
 		},
 	},
@@ -249,10 +425,10 @@ func TestIntegration_AccountsService_Create_Duplicate(t *testing.T) {
 			Type:           String("accounts"),
 			OrganisationId: String("58d8a2c8-29ca-11eb-adc1-0242ac120002"),
 			Attributes: &AccountAttributes{
-				Country:      String("GB"),
-				BaseCurrency: String("GBP"),
+				Country:      CountryGB.Ptr(),
+				BaseCurrency: CurrencyGBP.Ptr(),
 				BankId:       String("501600"),
-				BankIdCode:   String("GBDXN"),
+				BankIdCode:   BankIdCode("GBDXN").Ptr(),
 				BIC:          String("GDTKGB88"),
 			},
 		}
@@ -287,10 +463,10 @@ func TestIntegration_AccountsService_Fetch(t *testing.T) {
 		Type:           String("accounts"),
 		OrganisationId: String("eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"),
 		Attributes: &AccountAttributes{
-			Country:      String("GB"),
-			BaseCurrency: String("GBP"),
+			Country:      CountryGB.Ptr(),
+			BaseCurrency: CurrencyGBP.Ptr(),
 			BankId:       String("400300"),
-			BankIdCode:   String("GBDSC"),
+			BankIdCode:   BankIdCodeGBDSC.Ptr(),
 			BIC:          String("NWBKGB22"),
 		},
 	}
@@ -339,10 +515,10 @@ func TestIntegration_AccountsService_List(t *testing.T) {
 		Type:           String("accounts"),
 		OrganisationId: String("eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"),
 		Attributes: &AccountAttributes{
-			Country:      String("GB"),
-			BaseCurrency: String("GBP"),
+			Country:      CountryGB.Ptr(),
+			BaseCurrency: CurrencyGBP.Ptr(),
 			BankId:       String("400300"),
-			BankIdCode:   String("GBDSC"),
+			BankIdCode:   BankIdCodeGBDSC.Ptr(),
 			BIC:          String("NWBKGB22"),
 		},
 	},
@@ -351,10 +527,10 @@ func TestIntegration_AccountsService_List(t *testing.T) {
 			Type:           String("accounts"),
 			OrganisationId: String("58d8a2c8-29ca-11eb-adc1-0242ac120002"),
 			Attributes: &AccountAttributes{
-				Country:      String("GB"),
-				BaseCurrency: String("GBP"),
+				Country:      CountryGB.Ptr(),
+				BaseCurrency: CurrencyGBP.Ptr(),
 				BankId:       String("501600"),
-				BankIdCode:   String("GBDXN"),
+				BankIdCode:   BankIdCode("GBDXN").Ptr(),
 				BIC:          String("GDTKGB88"),
 			},
 		}
@@ -415,10 +591,10 @@ func TestIntegration_AccountsService_Delete(t *testing.T) {
 		Type:           String("accounts"),
 		OrganisationId: String("eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"),
 		Attributes: &AccountAttributes{
-			Country:      String("GB"),
-			BaseCurrency: String("GBP"),
+			Country:      CountryGB.Ptr(),
+			BaseCurrency: CurrencyGBP.Ptr(),
 			BankId:       String("400300"),
-			BankIdCode:   String("GBDSC"),
+			BankIdCode:   BankIdCodeGBDSC.Ptr(),
 			BIC:          String("NWBKGB22"),
 		},
 	}
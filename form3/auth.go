@@ -0,0 +1,226 @@
+package form3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenPath is the path of the OAuth 2.0 token endpoint, relative to the
+// BaseURL of the Client.
+const tokenPath = "oauth2/token"
+
+// tokenExpiryMargin is how long before its expiry an access token is renewed.
+const tokenExpiryMargin = 30 * time.Second
+
+// Credentials authenticate a Client with the Form3 API. Requests are
+// authenticated with an access token obtained with the OAuth 2.0 client
+// credentials grant if ClientID and ClientSecret are set, and signed with
+// an HTTP signature if SigningKeyID and Signer are set.
+//
+// Form3 API docs: https://api-docs.form3.tech/api.html#security
+type Credentials struct {
+	ClientID     string
+	ClientSecret string
+
+	// ID by which Form3 knows the public key of Signer.
+	SigningKeyID string
+	Signer       Signer
+}
+
+// validate checks that c has a complete set of client credentials, signing
+// credentials or both.
+func (c *Credentials) validate() error {
+	if (c.ClientID != "") != (c.ClientSecret != "") {
+		return errors.New("credentials must have both a client ID and a client secret")
+	}
+	if (c.SigningKeyID != "") != (c.Signer != nil) {
+		return errors.New("credentials must have both a signing key ID and a signer")
+	}
+	if c.ClientID == "" && c.Signer == nil {
+		return errors.New("credentials must have a client ID and secret or a signing key")
+	}
+	return nil
+}
+
+// A CredentialsProvider supplies the credentials a Client authenticates
+// requests with. The Client asks for credentials before every request, so a
+// provider can rotate them without the Client being recreated. Each request
+// is authenticated with the credentials returned for it, even if they are
+// rotated while it is in flight.
+//
+// Implementations must be safe for concurrent use, and must not modify
+// credentials they have returned.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (*Credentials, error)
+}
+
+// staticCredentials is a CredentialsProvider that never rotates.
+type staticCredentials struct {
+	credentials *Credentials
+}
+
+// StaticCredentials returns a CredentialsProvider that always provides
+// credentials.
+func StaticCredentials(credentials Credentials) CredentialsProvider {
+	return &staticCredentials{credentials: &credentials}
+}
+
+func (s *staticCredentials) Credentials(ctx context.Context) (*Credentials, error) {
+	return s.credentials, nil
+}
+
+// tokenResponse is the response of the token endpoint.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"` // seconds
+}
+
+// A tokenSource obtains access tokens for client credentials, caching the
+// last token until shortly before it expires or the credentials change. It
+// is safe for concurrent use.
+type tokenSource struct {
+	mu           sync.Mutex
+	clientID     string
+	clientSecret string
+	token        string
+	expiry       time.Time
+}
+
+// setCredentialsProvider makes the client authenticate requests with the
+// credentials from p.
+func (c *Client) setCredentialsProvider(p CredentialsProvider) {
+	c.credentials = p
+	c.tokens = new(tokenSource)
+}
+
+// An authorization is the credentials a call to Client.Do authenticates
+// with, carried in the request context to the Doer that authorizes each
+// attempt.
+type authorization struct {
+	client      *Client
+	credentials *Credentials
+}
+
+type authorizationKey struct{}
+
+// withCredentials returns ctx carrying the client's current credentials, if
+// it has any, so that every attempt of a call is authenticated with the same
+// credentials even if they are rotated meanwhile.
+func (c *Client) withCredentials(ctx context.Context) (context.Context, error) {
+	if c.credentials == nil {
+		return ctx, nil
+	}
+	credentials, err := c.credentials.Credentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting credentials: %v", err)
+	}
+	if credentials == nil {
+		return nil, errors.New("credentials provider returned no credentials")
+	}
+	return context.WithValue(ctx, authorizationKey{}, &authorization{client: c, credentials: credentials}), nil
+}
+
+// authorizationFrom returns the authorization carried by ctx, or nil.
+func authorizationFrom(ctx context.Context) *authorization {
+	a, _ := ctx.Value(authorizationKey{}).(*authorization)
+	return a
+}
+
+// authorizer returns a Doer that authenticates each request with the
+// credentials in its context before sending it with next. It is the
+// innermost Doer of a Client, so that each attempt made by middleware, such
+// as retries, is authenticated afresh, with a current access token and a
+// signature dated when it is sent.
+func authorizer(next Doer) Doer {
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		a := authorizationFrom(req.Context())
+		if a == nil {
+			return next.Do(req)
+		}
+		req = req.Clone(req.Context())
+		if err := a.client.authorize(req.Context(), req, a.credentials); err != nil {
+			return nil, err
+		}
+		return next.Do(req)
+	})
+}
+
+// authorize authenticates req with credentials, adding an access token, a
+// signature or both.
+func (c *Client) authorize(ctx context.Context, req *http.Request, credentials *Credentials) error {
+	if credentials.ClientID != "" {
+		token, err := c.tokens.get(ctx, c, credentials)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if credentials.Signer != nil {
+		if err := signRequest(ctx, req, credentials.SigningKeyID, credentials.Signer); err != nil {
+			return fmt.Errorf("signing request: %v", err)
+		}
+	}
+	return nil
+}
+
+// get returns a valid access token for credentials, requesting a new one
+// from the API if there is no cached token for them or it is about to
+// expire.
+func (ts *tokenSource) get(ctx context.Context, c *Client, credentials *Credentials) (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.token != "" && time.Now().Before(ts.expiry) &&
+		ts.clientID == credentials.ClientID && ts.clientSecret == credentials.ClientSecret {
+		return ts.token, nil
+	}
+
+	u, err := c.BaseURL.Parse(tokenPath)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequest("POST", u.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	req.SetBasicAuth(url.QueryEscape(credentials.ClientID), url.QueryEscape(credentials.ClientSecret))
+
+	// The token request is sent without middleware, so that the client
+	// secret is never passed to loggers or other instrumentation.
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting access token: %v", err)
+	}
+	defer resp.Body.Close()
+	if err := CheckResponse(resp); err != nil {
+		return "", err
+	}
+
+	token := new(tokenResponse)
+	if err := json.NewDecoder(resp.Body).Decode(token); err != nil {
+		return "", fmt.Errorf("decoding access token: %v", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("no access token in response from %v", u)
+	}
+
+	ts.clientID, ts.clientSecret = credentials.ClientID, credentials.ClientSecret
+	ts.token = token.AccessToken
+	ts.expiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenExpiryMargin)
+	return ts.token, nil
+}
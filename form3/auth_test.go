@@ -0,0 +1,99 @@
+package form3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestUnit_Client_Credentials(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi(WithCredentials(Credentials{ClientID: "client", ClientSecret: "secret"}))
+	defer teardown()
+
+	tokenRequests := 0
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		testMethod(t, r, "POST")
+		testFormValues(t, r, values{"grant_type": "client_credentials"})
+		if id, secret, _ := r.BasicAuth(); id != "client" || secret != "secret" {
+			t.Errorf("Token request basic auth = %q:%q, want client:secret", id, secret)
+		}
+		fmt.Fprint(w, `{"access_token": "token-1", "token_type": "bearer", "expires_in": 3600}`)
+	})
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "Authorization", "Bearer token-1")
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := client.Accounts.Fetch(context.Background(), "1"); err != nil {
+			t.Fatalf("Fetch returned error: %v", err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("Token requested %d times, want 1", tokenRequests)
+	}
+}
+
+func TestUnit_Client_Credentials_Expired(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi(WithCredentials(Credentials{ClientID: "client", ClientSecret: "secret"}))
+	defer teardown()
+
+	tokenRequests := 0
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "bearer", "expires_in": 1}`, tokenRequests)
+	})
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "Authorization", fmt.Sprintf("Bearer token-%d", tokenRequests))
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+
+	for i := 0; i < 2; i++ {
+		client.Accounts.Fetch(context.Background(), "1")
+	}
+	if tokenRequests != 2 {
+		t.Errorf("Token requested %d times, want 2", tokenRequests)
+	}
+}
+
+func TestUnit_Client_Credentials_Rejected(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi(WithCredentials(Credentials{ClientID: "client", ClientSecret: "wrong"}))
+	defer teardown()
+
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error_message": "invalid client credentials"}`)
+	})
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Request sent without a valid access token")
+	})
+
+	_, _, err := client.Accounts.Fetch(context.Background(), "1")
+	if errorResponse, ok := err.(*ErrorResponse); !ok || errorResponse.Response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Fetch returned error %v, want 401 error response", err)
+	}
+}
+
+func TestUnit_Client_Credentials_Rotated(t *testing.T) {
+	provider := new(rotatingCredentials)
+	provider.current.Store(&Credentials{ClientID: "client", ClientSecret: "secret-1"})
+	client, mux, _, teardown := setupClientWithStubbedApi(WithCredentialsProvider(provider))
+	defer teardown()
+
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		_, secret, _ := r.BasicAuth()
+		fmt.Fprintf(w, `{"access_token": "token-for-%s", "token_type": "bearer", "expires_in": 3600}`, secret)
+	})
+	for _, secret := range []string{"secret-1", "secret-2"} {
+		provider.current.Store(&Credentials{ClientID: "client", ClientSecret: secret})
+		mux.HandleFunc("/organisation/accounts/"+secret, func(w http.ResponseWriter, r *http.Request) {
+			testHeader(t, r, "Authorization", "Bearer token-for-"+secret)
+			fmt.Fprint(w, `{"data": {}}`)
+		})
+		if _, _, err := client.Accounts.Fetch(context.Background(), secret); err != nil {
+			t.Fatalf("Fetch returned error: %v", err)
+		}
+	}
+}
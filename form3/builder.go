@@ -0,0 +1,396 @@
+package form3
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits on account holder names.
+const (
+	maxNameLines            = 4
+	maxAlternativeNames     = 3
+	maxNameLength           = 140
+	maxSecondaryIdentLength = 140
+)
+
+var (
+	bicPattern  = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+	ibanPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{1,30}$`)
+)
+
+// countryRules are the attributes Form3 requires of an account in a country.
+//
+// Form3 API docs: https://api-docs.form3.tech/api.html#organisation-accounts-resource
+type countryRules struct {
+	bankID          presence
+	bankIDLengths   []int // allowed lengths of the bank ID
+	bankIDPrefix    string
+	bankIdCode      BankIdCode // required code, if the country has a bank ID
+	bic             presence
+	accountLengths  [2]int // minimum and maximum length of the account number
+	ibanUnsupported bool   // whether the IBAN must be empty
+}
+
+type presence int
+
+const (
+	optional presence = iota
+	required
+	unsupported
+)
+
+var accountCountryRules = map[Country]countryRules{
+	CountryAU: {bankID: optional, bankIDLengths: []int{6}, bankIdCode: BankIdCodeAUBSB, bic: required, accountLengths: [2]int{6, 10}, ibanUnsupported: true},
+	CountryBE: {bankID: required, bankIDLengths: []int{3}, bankIdCode: BankIdCodeBE, bic: optional, accountLengths: [2]int{7, 7}},
+	CountryCA: {bankID: optional, bankIDLengths: []int{9}, bankIDPrefix: "0", bankIdCode: BankIdCodeCACPA, bic: required, accountLengths: [2]int{7, 12}, ibanUnsupported: true},
+	CountryCH: {bankID: required, bankIDLengths: []int{5}, bankIdCode: BankIdCodeCHBCC, bic: optional, accountLengths: [2]int{12, 12}},
+	CountryDE: {bankID: required, bankIDLengths: []int{8}, bankIdCode: BankIdCodeDEBLZ, bic: optional, accountLengths: [2]int{7, 7}},
+	CountryES: {bankID: required, bankIDLengths: []int{8}, bankIdCode: BankIdCodeESNCC, bic: optional, accountLengths: [2]int{10, 10}},
+	CountryFR: {bankID: required, bankIDLengths: []int{10}, bankIdCode: BankIdCodeFR, bic: optional, accountLengths: [2]int{10, 10}},
+	CountryGB: {bankID: required, bankIDLengths: []int{6}, bankIdCode: BankIdCodeGBDSC, bic: required, accountLengths: [2]int{8, 8}},
+	CountryGR: {bankID: required, bankIDLengths: []int{7}, bankIdCode: BankIdCodeGRBIC, bic: optional, accountLengths: [2]int{16, 16}},
+	CountryHK: {bankID: optional, bankIDLengths: []int{3}, bankIdCode: BankIdCodeHKNCC, bic: required, accountLengths: [2]int{9, 12}, ibanUnsupported: true},
+	CountryIE: {bankID: required, bankIDLengths: []int{6}, bankIdCode: BankIdCodeGBDSC, bic: required, accountLengths: [2]int{8, 8}},
+	CountryIT: {bankID: required, bankIDLengths: []int{10, 11}, bankIdCode: BankIdCodeITNCC, bic: optional, accountLengths: [2]int{12, 12}},
+	CountryLU: {bankID: required, bankIDLengths: []int{3}, bankIdCode: BankIdCodeLULUX, bic: optional, accountLengths: [2]int{13, 13}},
+	CountryNL: {bankID: unsupported, bic: required, accountLengths: [2]int{10, 10}},
+	CountryPL: {bankID: required, bankIDLengths: []int{8}, bankIdCode: BankIdCodePLKNR, bic: optional, accountLengths: [2]int{16, 16}},
+	CountryPT: {bankID: required, bankIDLengths: []int{8}, bankIdCode: BankIdCodePTNCC, bic: optional, accountLengths: [2]int{11, 11}},
+	CountryUS: {bankID: required, bankIDLengths: []int{9}, bankIdCode: BankIdCodeUSABA, bic: required, accountLengths: [2]int{6, 17}, ibanUnsupported: true},
+}
+
+// A FieldError describes an invalid or missing attribute of a resource.
+type FieldError struct {
+	Field   string // JSON name of the attribute, e.g. "bank_id"
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// A ValidationError lists the problems found when validating a resource.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return "invalid account: " + strings.Join(msgs, "; ")
+}
+
+// An AccountBuilder constructs an Account for a country, validating it
+// against the rules Form3 applies in that country. Its methods can be
+// chained:
+//
+//	account, err := form3.NewAccountBuilder("GB").
+//		SortCode("40-03-00").
+//		AccountNumber("41426819").
+//		BIC("NWBKGB22").
+//		Name("Samantha Holder").
+//		Build()
+type AccountBuilder struct {
+	id             string
+	organisationID string
+	attributes     AccountAttributes
+}
+
+// NewAccountBuilder returns an AccountBuilder for an account in country.
+func NewAccountBuilder(country Country) *AccountBuilder {
+	b := &AccountBuilder{}
+	b.attributes.Country = country.Ptr()
+	if rules, ok := accountCountryRules[country]; ok && rules.bankIdCode != "" {
+		b.attributes.BankIdCode = rules.bankIdCode.Ptr()
+	}
+	return b
+}
+
+// ID sets the ID of the account. If it is not set, one is generated when the
+// account is created.
+func (b *AccountBuilder) ID(id string) *AccountBuilder {
+	b.id = id
+	return b
+}
+
+// OrganisationID sets the organisation of the account. If it is not set, the
+// client's organisation is used when the account is created.
+func (b *AccountBuilder) OrganisationID(organisationID string) *AccountBuilder {
+	b.organisationID = organisationID
+	return b
+}
+
+// BankID sets the local bank identifier. The bank ID code is set to the one
+// used by the country.
+func (b *AccountBuilder) BankID(bankID string) *AccountBuilder {
+	b.attributes.BankId = String(bankID)
+	return b
+}
+
+// SortCode sets the bank ID to a UK sort code, which may contain dashes or
+// spaces, e.g. "40-03-00".
+func (b *AccountBuilder) SortCode(sortCode string) *AccountBuilder {
+	sortCode = strings.NewReplacer("-", "", " ", "").Replace(sortCode)
+	b.attributes.BankIdCode = BankIdCodeGBDSC.Ptr()
+	return b.BankID(sortCode)
+}
+
+// BankIdCode overrides the bank ID code chosen for the country.
+func (b *AccountBuilder) BankIdCode(code BankIdCode) *AccountBuilder {
+	b.attributes.BankIdCode = code.Ptr()
+	return b
+}
+
+// BIC sets the SWIFT BIC of the bank.
+func (b *AccountBuilder) BIC(bic string) *AccountBuilder {
+	b.attributes.BIC = String(bic)
+	return b
+}
+
+// AccountNumber sets the account number. Form3 generates one if it is not
+// set.
+func (b *AccountBuilder) AccountNumber(accountNumber string) *AccountBuilder {
+	b.attributes.AccountNumber = String(accountNumber)
+	return b
+}
+
+// IBAN sets the IBAN of the account, which may contain spaces. Form3
+// calculates it if it is not set, in countries that use IBANs.
+func (b *AccountBuilder) IBAN(iban string) *AccountBuilder {
+	b.attributes.IBAN = String(strings.ReplaceAll(iban, " ", ""))
+	return b
+}
+
+// BaseCurrency sets the base currency of the account.
+func (b *AccountBuilder) BaseCurrency(currency Currency) *AccountBuilder {
+	b.attributes.BaseCurrency = currency.Ptr()
+	return b
+}
+
+// CustomerID sets the reference linking the account to an external system.
+func (b *AccountBuilder) CustomerID(customerID string) *AccountBuilder {
+	b.attributes.CustomerId = String(customerID)
+	return b
+}
+
+// Name sets the name of the account holder, one line per argument.
+func (b *AccountBuilder) Name(lines ...string) *AccountBuilder {
+	b.attributes.Name = lines
+	return b
+}
+
+// AlternativeNames sets the alternative names of the account holder, used
+// for UK Confirmation of Payee.
+func (b *AccountBuilder) AlternativeNames(names ...string) *AccountBuilder {
+	b.attributes.AlternativeNames = names
+	return b
+}
+
+// Classification sets the classification of the account.
+func (b *AccountBuilder) Classification(classification AccountClassification) *AccountBuilder {
+	b.attributes.AccountClassification = classification.Ptr()
+	return b
+}
+
+// JointAccount sets whether the account is a joint account.
+func (b *AccountBuilder) JointAccount(joint bool) *AccountBuilder {
+	b.attributes.JointAccount = Bool(joint)
+	return b
+}
+
+// AccountMatchingOptOut sets whether the account has opted out of account
+// matching.
+func (b *AccountBuilder) AccountMatchingOptOut(optOut bool) *AccountBuilder {
+	b.attributes.AccountMatchingOptOut = Bool(optOut)
+	return b
+}
+
+// SecondaryIdentification sets additional information identifying the
+// account, such as a building society roll number.
+func (b *AccountBuilder) SecondaryIdentification(identification string) *AccountBuilder {
+	b.attributes.SecondaryIdentification = String(identification)
+	return b
+}
+
+// Switched sets whether the account has been switched away from the
+// organisation.
+func (b *AccountBuilder) Switched(switched bool) *AccountBuilder {
+	b.attributes.Switched = Bool(switched)
+	return b
+}
+
+// Build returns the account, or a *ValidationError listing every missing or
+// invalid attribute.
+func (b *AccountBuilder) Build() (*Account, error) {
+	attributes := b.attributes
+	if err := ValidateAccountAttributes(&attributes); err != nil {
+		return nil, err
+	}
+
+	account := &Account{Type: String(accountsType), Attributes: &attributes}
+	if b.id != "" {
+		account.ID = String(b.id)
+	}
+	if b.organisationID != "" {
+		account.OrganisationId = String(b.organisationID)
+	}
+	return account, nil
+}
+
+// ValidateAccountAttributes checks attributes against the rules Form3
+// applies to accounts in their country. It returns a *ValidationError listing
+// every missing or invalid attribute, or nil if they are valid.
+func ValidateAccountAttributes(attributes *AccountAttributes) error {
+	v := new(ValidationError)
+	add := func(field, format string, args ...interface{}) {
+		v.Errors = append(v.Errors, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if attributes.Country == nil {
+		add("country", "is required")
+		return v
+	}
+	country := *attributes.Country
+	rules, ok := accountCountryRules[country]
+	if !ok {
+		add("country", "%q is not supported", country)
+		return v
+	}
+
+	bankID := stringValue(attributes.BankId)
+	switch {
+	case rules.bankID == required && bankID == "":
+		add("bank_id", "is required in %s", country)
+	case rules.bankID == unsupported && bankID != "":
+		add("bank_id", "is not supported in %s", country)
+	case bankID != "" && !containsInt(rules.bankIDLengths, len(bankID)):
+		add("bank_id", "must be %s characters in %s", joinInts(rules.bankIDLengths), country)
+	case bankID != "" && !strings.HasPrefix(bankID, rules.bankIDPrefix):
+		add("bank_id", "must start with %q in %s", rules.bankIDPrefix, country)
+	}
+
+	code := BankIdCode("")
+	if attributes.BankIdCode != nil {
+		code = *attributes.BankIdCode
+	}
+	switch {
+	case rules.bankIdCode == "" && code != "":
+		add("bank_id_code", "is not supported in %s", country)
+	case rules.bankIdCode != "" && bankID != "" && code != rules.bankIdCode:
+		add("bank_id_code", "must be %s in %s", rules.bankIdCode, country)
+	}
+
+	bic := stringValue(attributes.BIC)
+	switch {
+	case rules.bic == required && bic == "":
+		add("bic", "is required in %s", country)
+	case bic != "" && !bicPattern.MatchString(bic):
+		add("bic", "%q is not an 8 or 11 character SWIFT BIC", bic)
+	}
+
+	if accountNumber := stringValue(attributes.AccountNumber); accountNumber != "" {
+		min, max := rules.accountLengths[0], rules.accountLengths[1]
+		if len(accountNumber) < min || len(accountNumber) > max {
+			if min == max {
+				add("account_number", "must be %d characters in %s", min, country)
+			} else {
+				add("account_number", "must be %d to %d characters in %s", min, max, country)
+			}
+		}
+	}
+
+	if iban := stringValue(attributes.IBAN); iban != "" {
+		switch {
+		case rules.ibanUnsupported:
+			add("iban", "is not supported in %s", country)
+		case !strings.HasPrefix(iban, string(country)):
+			add("iban", "must start with %s", country)
+		case !validIBAN(iban):
+			add("iban", "%q is not a valid IBAN", iban)
+		}
+	}
+
+	if attributes.BaseCurrency != nil && !attributes.BaseCurrency.IsValid() {
+		add("base_currency", "%q is not an ISO 4217 currency", *attributes.BaseCurrency)
+	}
+	if attributes.AccountClassification != nil && !attributes.AccountClassification.IsValid() {
+		add("account_classification", "%q is not a known classification", *attributes.AccountClassification)
+	}
+	if attributes.Status != nil && !attributes.Status.IsValid() {
+		add("status", "%q is not a known status", *attributes.Status)
+	}
+
+	validateNames(add, "name", attributes.Name, maxNameLines, true)
+	validateNames(add, "alternative_names", attributes.AlternativeNames, maxAlternativeNames, false)
+	if utf8.RuneCountInString(stringValue(attributes.SecondaryIdentification)) > maxSecondaryIdentLength {
+		add("secondary_identification", "must be at most %d characters", maxSecondaryIdentLength)
+	}
+
+	if len(v.Errors) > 0 {
+		return v
+	}
+	return nil
+}
+
+func validateNames(add func(field, format string, args ...interface{}), field string, names []string, maxLines int, isRequired bool) {
+	if isRequired && len(names) == 0 {
+		add(field, "is required")
+	}
+	if len(names) > maxLines {
+		add(field, "must have at most %d lines", maxLines)
+	}
+	for i, name := range names {
+		if strings.TrimSpace(name) == "" {
+			add(field, "line %d is empty", i+1)
+		} else if utf8.RuneCountInString(name) > maxNameLength {
+			add(field, "line %d must be at most %d characters", i+1, maxNameLength)
+		}
+	}
+}
+
+// validIBAN checks the format and ISO 7064 mod 97 check digits of an IBAN.
+func validIBAN(iban string) bool {
+	if !ibanPattern.MatchString(iban) {
+		return false
+	}
+	rearranged := iban[4:] + iban[:4]
+	var digits strings.Builder
+	for _, r := range rearranged {
+		if r >= 'A' && r <= 'Z' {
+			fmt.Fprintf(&digits, "%d", r-'A'+10)
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func joinInts(values []int) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = fmt.Sprint(v)
+	}
+	return strings.Join(s, " or ")
+}
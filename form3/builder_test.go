@@ -0,0 +1,138 @@
+package form3
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestUnit_AccountBuilder_GB(t *testing.T) {
+	account, err := NewAccountBuilder("GB").
+		ID("1d50df61-db36-483c-9975-41141d691be1").
+		SortCode("40-03-00").
+		AccountNumber("41426819").
+		BIC("NWBKGB22").
+		BaseCurrency(CurrencyGBP).
+		Name("Samantha Holder").
+		Classification(ClassificationPersonal).
+		Build()
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+
+	want := &Account{
+		ID:   String("1d50df61-db36-483c-9975-41141d691be1"),
+		Type: String("accounts"),
+		Attributes: &AccountAttributes{
+			Country:               CountryGB.Ptr(),
+			BaseCurrency:          CurrencyGBP.Ptr(),
+			BankId:                String("400300"),
+			BankIdCode:            BankIdCodeGBDSC.Ptr(),
+			BIC:                   String("NWBKGB22"),
+			AccountNumber:         String("41426819"),
+			Name:                  []string{"Samantha Holder"},
+			AccountClassification: ClassificationPersonal.Ptr(),
+		},
+	}
+	if !reflect.DeepEqual(account, want) {
+		t.Errorf("Build = %+v, want %+v", account.Attributes, want.Attributes)
+	}
+}
+
+func TestUnit_AccountBuilder_Countries(t *testing.T) {
+	tests := []struct {
+		country Country
+		build   func(*AccountBuilder) *AccountBuilder
+	}{
+		{"GB", func(b *AccountBuilder) *AccountBuilder {
+			return b.BankID("601613").BIC("NWBKGB22").IBAN("GB29 NWBK 6016 1331 9268 19")
+		}},
+		{"DE", func(b *AccountBuilder) *AccountBuilder { return b.BankID("37040044").IBAN("DE89370400440532013000") }},
+		{"FR", func(b *AccountBuilder) *AccountBuilder { return b.BankID("2004101005").AccountNumber("0500013M02") }},
+		{"ES", func(b *AccountBuilder) *AccountBuilder { return b.BankID("21000418").IBAN("ES9121000418450200051332") }},
+		{"IT", func(b *AccountBuilder) *AccountBuilder { return b.BankID("X054281110").AccountNumber("000000123456") }},
+		{"NL", func(b *AccountBuilder) *AccountBuilder { return b.BIC("ABNANL2A").IBAN("NL91ABNA0417164300") }},
+		{"BE", func(b *AccountBuilder) *AccountBuilder { return b.BankID("539").AccountNumber("0075470") }},
+		{"IE", func(b *AccountBuilder) *AccountBuilder {
+			return b.BankID("931152").BIC("AIBKIE2D").IBAN("IE29AIBK93115212345678")
+		}},
+		{"IE", func(b *AccountBuilder) *AccountBuilder {
+			return b.SortCode("93-11-52").BIC("AIBKIE2D").AccountNumber("12345678")
+		}},
+		{"PT", func(b *AccountBuilder) *AccountBuilder { return b.BankID("00020123").IBAN("PT50000201231234567890154") }},
+		{"CH", func(b *AccountBuilder) *AccountBuilder { return b.BankID("00762").IBAN("CH9300762011623852957") }},
+		{"AU", func(b *AccountBuilder) *AccountBuilder {
+			return b.BankID("082902").BIC("NATAAU33").AccountNumber("123456789")
+		}},
+		{"CA", func(b *AccountBuilder) *AccountBuilder {
+			return b.BankID("012345678").BIC("ROYCCAT2").AccountNumber("1234567")
+		}},
+	}
+
+	for _, tt := range tests {
+		b := tt.build(NewAccountBuilder(tt.country).Name("Samantha Holder"))
+		account, err := b.Build()
+		if err != nil {
+			t.Errorf("Build for %s returned error: %v", tt.country, err)
+			continue
+		}
+		if rules := accountCountryRules[tt.country]; account.Attributes.BankId != nil && (account.Attributes.BankIdCode == nil || *account.Attributes.BankIdCode != rules.bankIdCode) {
+			t.Errorf("Build for %s set bank ID code %v, want %v", tt.country, account.Attributes.BankIdCode, rules.bankIdCode)
+		}
+	}
+}
+
+func fieldsWithErrors(err error) []string {
+	v, ok := err.(*ValidationError)
+	if !ok {
+		return nil
+	}
+	var fields []string
+	for _, fe := range v.Errors {
+		fields = append(fields, fe.Field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func TestUnit_AccountBuilder_NonASCIIName(t *testing.T) {
+	name := strings.Repeat("é", maxNameLength) // 280 bytes
+	if _, err := NewAccountBuilder("NL").BIC("ABNANL2A").Name(name).Build(); err != nil {
+		t.Errorf("Build with a name of 140 characters returned error: %v", err)
+	}
+}
+
+func TestUnit_AccountBuilder_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *AccountBuilder
+		want    []string
+	}{
+		{"GB missing bank ID, BIC and name", NewAccountBuilder("GB"), []string{"bank_id", "bic", "name"}},
+		{"unsupported country", NewAccountBuilder("ZZ").Name("Samantha Holder"), []string{"country"}},
+		{"wrong bank ID length", NewAccountBuilder("DE").BankID("3704004").Name("S Holder"), []string{"bank_id"}},
+		{"bank ID in NL", NewAccountBuilder("NL").BankID("ABNA").BIC("ABNANL2A").Name("S Holder"), []string{"bank_id"}},
+		{"CA bank ID prefix", NewAccountBuilder("CA").BankID("123456789").BIC("ROYCCAT2").Name("S Holder"), []string{"bank_id"}},
+		{"wrong bank ID code", NewAccountBuilder("GB").BankID("400300").BankIdCode(BankIdCodeDEBLZ).BIC("NWBKGB22").Name("S Holder"), []string{"bank_id_code"}},
+		{"invalid BIC", NewAccountBuilder("GB").SortCode("400300").BIC("NWBK").Name("S Holder"), []string{"bic"}},
+		{"account number length", NewAccountBuilder("GB").SortCode("400300").BIC("NWBKGB22").AccountNumber("123").Name("S Holder"), []string{"account_number"}},
+		{"IBAN check digits", NewAccountBuilder("DE").BankID("37040044").IBAN("DE88370400440532013000").Name("S Holder"), []string{"iban"}},
+		{"IBAN country", NewAccountBuilder("FR").BankID("2004101005").IBAN("DE89370400440532013000").Name("S Holder"), []string{"iban"}},
+		{"IBAN in AU", NewAccountBuilder("AU").BIC("NATAAU33").IBAN("GB29NWBK60161331926819").Name("S Holder"), []string{"iban"}},
+		{"too many name lines", NewAccountBuilder("NL").BIC("ABNANL2A").Name("a", "b", "c", "d", "e"), []string{"name"}},
+		{"too many alternative names", NewAccountBuilder("NL").BIC("ABNANL2A").Name("a").AlternativeNames("a", "b", "c", "d"), []string{"alternative_names"}},
+		{"name too long", NewAccountBuilder("NL").BIC("ABNANL2A").Name(strings.Repeat("é", 141)), []string{"name"}},
+		{"unknown currency", NewAccountBuilder("NL").BIC("ABNANL2A").Name("a").BaseCurrency("EURO"), []string{"base_currency"}},
+	}
+
+	for _, tt := range tests {
+		account, err := tt.builder.Build()
+		if account != nil {
+			t.Errorf("Build with %s returned account %+v", tt.name, account.Attributes)
+		}
+		if got := fieldsWithErrors(err); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Build with %s returned errors for %v, want %v (%v)", tt.name, got, tt.want, err)
+		}
+	}
+}
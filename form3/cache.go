@@ -0,0 +1,244 @@
+package form3
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheHeader is set on responses served from a response cache.
+const cacheHeader = "X-From-Cache"
+
+// A CachedResponse is a GET response held in a CacheStore.
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	StoredAt   time.Time // when the response was stored or last revalidated
+}
+
+// A CacheStore holds cached responses by key. Implementations must be safe
+// for concurrent use.
+type CacheStore interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse)
+	Delete(key string)
+}
+
+// CacheOptions configures CacheMiddleware.
+type CacheOptions struct {
+	// Storage for cached responses. Defaults to an LRU store holding 1000
+	// responses.
+	Store CacheStore
+
+	// How long a response is served from the cache without contacting the
+	// API. Once it has expired, a response with an ETag or Last-Modified
+	// header is revalidated with a conditional request; others are fetched
+	// again. Defaults to one minute.
+	TTL time.Duration
+}
+
+// CacheMiddleware returns a Middleware that caches successful GET responses,
+// such as those of AccountsService.Fetch.
+//
+// A successful non-GET request for a resource, such as an update or a
+// delete, invalidates the cached response for that resource. Cached list
+// responses are not invalidated, and expire after the TTL.
+//
+// Responses are cached by the credentials of the request as well as its
+// URL, so that clients with different credentials sharing the middleware,
+// such as views returned by Client.ForOrganisation, never see each other's
+// responses.
+func CacheMiddleware(opts CacheOptions) Middleware {
+	if opts.Store == nil {
+		opts.Store = NewLRUCacheStore(1000)
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = time.Minute
+	}
+
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodGet {
+				resp, err := next.Do(req)
+				if err == nil && resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+					opts.Store.Delete(cacheKey(req, resourceKey(req)))
+				}
+				return resp, err
+			}
+
+			key := cacheKey(req, req.URL.String())
+			cached, ok := opts.Store.Get(key)
+			if ok && time.Since(cached.StoredAt) < opts.TTL {
+				return cached.response(req), nil
+			}
+
+			revalidating := ok && (cached.Header.Get("ETag") != "" || cached.Header.Get("Last-Modified") != "")
+			if revalidating {
+				req = req.Clone(req.Context())
+				if etag := cached.Header.Get("ETag"); etag != "" {
+					req.Header.Set("If-None-Match", etag)
+				}
+				if modified := cached.Header.Get("Last-Modified"); modified != "" {
+					req.Header.Set("If-Modified-Since", modified)
+				}
+			}
+
+			resp, err := next.Do(req)
+			if err != nil {
+				return nil, err
+			}
+
+			if revalidating && resp.StatusCode == http.StatusNotModified {
+				resp.Body.Close()
+				refreshed := *cached
+				refreshed.StoredAt = time.Now()
+				opts.Store.Set(key, &refreshed)
+				return refreshed.response(req), nil
+			}
+
+			if resp.StatusCode != http.StatusOK || strings.Contains(resp.Header.Get("Cache-Control"), "no-store") {
+				if ok {
+					opts.Store.Delete(key)
+				}
+				return resp, nil
+			}
+
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+			opts.Store.Set(key, &CachedResponse{
+				StatusCode: resp.StatusCode,
+				Header:     resp.Header.Clone(),
+				Body:       body,
+				StoredAt:   time.Now(),
+			})
+			return resp, nil
+		})
+	}
+}
+
+// cacheKey returns the key of a cached response to req for the URL u,
+// qualified by the identity req is authenticated as: the client ID or
+// signing key ID of the credentials it is sent with, or failing those a
+// hash of its access token or the ID of the key that signed it.
+func cacheKey(req *http.Request, u string) string {
+	if a := authorizationFrom(req.Context()); a != nil {
+		if a.credentials.ClientID != "" {
+			return "client:" + a.credentials.ClientID + " " + u
+		}
+		return "key:" + a.credentials.SigningKeyID + " " + u
+	}
+	authorization := req.Header.Get("Authorization")
+	switch {
+	case strings.HasPrefix(authorization, "Bearer "):
+		sum := sha256.Sum256([]byte(authorization))
+		return "token:" + hex.EncodeToString(sum[:8]) + " " + u
+	case strings.HasPrefix(authorization, "Signature "):
+		keyID := strings.TrimPrefix(authorization, "Signature ")
+		if i := strings.Index(keyID, `keyId="`); i >= 0 {
+			keyID = keyID[i+len(`keyId="`):]
+			keyID = keyID[:strings.IndexByte(keyID+`"`, '"')]
+		}
+		return "key:" + keyID + " " + u
+	}
+	return u
+}
+
+// resourceKey returns the cache key of the resource a request acts on: its
+// URL without the query, which for a delete carries the version.
+func resourceKey(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+// response returns an http.Response for req built from the cached response.
+func (c *CachedResponse) response(req *http.Request) *http.Response {
+	header := c.Header.Clone()
+	header.Set(cacheHeader, "1")
+	return &http.Response{
+		Status:        http.StatusText(c.StatusCode),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
+
+// lruCacheStore is an in-memory CacheStore that evicts the least recently
+// used response once it is full.
+type lruCacheStore struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // most recently used at the front
+}
+
+type lruEntry struct {
+	key  string
+	resp *CachedResponse
+}
+
+// NewLRUCacheStore returns an in-memory CacheStore holding up to capacity
+// responses, evicting the least recently used when full.
+func NewLRUCacheStore(capacity int) CacheStore {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &lruCacheStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (s *lruCacheStore) Get(key string) (*CachedResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	s.order.MoveToFront(e)
+	return e.Value.(*lruEntry).resp, true
+}
+
+func (s *lruCacheStore) Set(key string, resp *CachedResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		e.Value.(*lruEntry).resp = resp
+		s.order.MoveToFront(e)
+		return
+	}
+	s.entries[key] = s.order.PushFront(&lruEntry{key: key, resp: resp})
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+func (s *lruCacheStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		s.order.Remove(e)
+		delete(s.entries, key)
+	}
+}
@@ -0,0 +1,176 @@
+package form3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const cachedAccountID = "d97a4470-299f-11eb-adc1-0242ac120002"
+
+func TestUnit_CacheMiddleware(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi(WithMiddleware(CacheMiddleware(CacheOptions{TTL: time.Hour})))
+	defer teardown()
+
+	requests := 0
+	mux.HandleFunc("/organisation/accounts/"+cachedAccountID, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, `{"data": {"id": %q, "version": 0}}`, cachedAccountID)
+	})
+
+	for i := 0; i < 3; i++ {
+		account, resp, err := client.Accounts.Fetch(context.Background(), cachedAccountID)
+		if err != nil {
+			t.Fatalf("Fetch returned error: %v", err)
+		}
+		if got, want := *account.Data.ID, cachedAccountID; got != want {
+			t.Errorf("Fetch returned ID %v, want %v", got, want)
+		}
+		if fromCache := resp.Header.Get(cacheHeader) != ""; fromCache != (i > 0) {
+			t.Errorf("Fetch %d served from cache: %v", i, fromCache)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Server received %d requests, want 1", requests)
+	}
+}
+
+func TestUnit_CacheMiddleware_Revalidate(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi(WithMiddleware(CacheMiddleware(CacheOptions{TTL: time.Nanosecond})))
+	defer teardown()
+
+	requests := 0
+	mux.HandleFunc("/organisation/accounts/"+cachedAccountID, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v0"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v0"`)
+		fmt.Fprintf(w, `{"data": {"id": %q, "version": 0}}`, cachedAccountID)
+	})
+
+	client.Accounts.Fetch(context.Background(), cachedAccountID)
+	account, resp, err := client.Accounts.Fetch(context.Background(), cachedAccountID)
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("Revalidated Fetch returned status %v, want %v", got, want)
+	}
+	if account.Data == nil || *account.Data.ID != cachedAccountID {
+		t.Errorf("Revalidated Fetch returned %+v, want cached account", account.Data)
+	}
+	if requests != 2 {
+		t.Errorf("Server received %d requests, want 2", requests)
+	}
+}
+
+func TestUnit_CacheMiddleware_Invalidate(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi(WithMiddleware(CacheMiddleware(CacheOptions{TTL: time.Hour})))
+	defer teardown()
+
+	version := 0
+	fetches := 0
+	mux.HandleFunc("/organisation/accounts/"+cachedAccountID, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fetches++
+		case "PATCH":
+			version++
+		case "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		fmt.Fprintf(w, `{"data": {"id": %q, "version": %d}}`, cachedAccountID, version)
+	})
+
+	fetch := func() int {
+		account, _, err := client.Accounts.Fetch(context.Background(), cachedAccountID)
+		if err != nil {
+			t.Fatalf("Fetch returned error: %v", err)
+		}
+		return *account.Data.Version
+	}
+
+	fetch()
+	if _, _, err := client.Accounts.Update(context.Background(), &Account{ID: String(cachedAccountID), Version: Int(0)}); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if got := fetch(); got != 1 {
+		t.Errorf("Fetch after Update returned version %d, want 1", got)
+	}
+
+	if _, err := client.Accounts.Delete(context.Background(), cachedAccountID, 1); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	fetch()
+	if fetches != 3 {
+		t.Errorf("Server received %d fetches, want 3", fetches)
+	}
+}
+
+func TestUnit_LRUCacheStore(t *testing.T) {
+	store := NewLRUCacheStore(2)
+	store.Set("a", &CachedResponse{StatusCode: 200})
+	store.Set("b", &CachedResponse{StatusCode: 200})
+	store.Get("a")
+	store.Set("c", &CachedResponse{StatusCode: 200})
+
+	if _, ok := store.Get("b"); ok {
+		t.Error("Least recently used entry was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := store.Get(key); !ok {
+			t.Errorf("Entry %q was evicted", key)
+		}
+	}
+
+	store.Delete("a")
+	if _, ok := store.Get("a"); ok {
+		t.Error("Deleted entry is still stored")
+	}
+}
+
+func TestUnit_CacheKey(t *testing.T) {
+	u := "https://api.form3.tech/v1/organisation/accounts/1"
+	key := func(authorization string) string {
+		req, _ := http.NewRequest("GET", u, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		return cacheKey(req, u)
+	}
+
+	if got := key(""); got != u {
+		t.Errorf("cacheKey without credentials = %v, want %v", got, u)
+	}
+	if key("Bearer token-a") == key("Bearer token-b") {
+		t.Error("cacheKey is the same for different access tokens")
+	}
+	signed := func(keyID, signature string) string {
+		return key(fmt.Sprintf(`Signature keyId="%s",algorithm="rsa-sha256",headers="date",signature="%s"`, keyID, signature))
+	}
+	if signed("key-1", "a") != signed("key-1", "b") {
+		t.Error("cacheKey differs for requests signed with the same key")
+	}
+	if signed("key-1", "a") == signed("key-2", "a") {
+		t.Error("cacheKey is the same for requests signed with different keys")
+	}
+
+	// Requests sent by a Client carry their credentials in their context,
+	// as they are only authorized after the middleware.
+	sent := func(credentials *Credentials) string {
+		ctx := context.WithValue(context.Background(), authorizationKey{}, &authorization{credentials: credentials})
+		req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+		return cacheKey(req, u)
+	}
+	if sent(&Credentials{ClientID: "client-1"}) == sent(&Credentials{ClientID: "client-2"}) {
+		t.Error("cacheKey is the same for requests sent with different client IDs")
+	}
+	if sent(&Credentials{SigningKeyID: "key-1"}) == sent(&Credentials{SigningKeyID: "key-2"}) {
+		t.Error("cacheKey is the same for requests sent with different signing keys")
+	}
+}
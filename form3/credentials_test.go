@@ -0,0 +1,175 @@
+package form3
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeCredentialsFile writes a credentials file, moving its modification
+// time on so that the change is seen however quickly the file is rewritten.
+func writeCredentialsFile(t *testing.T, path string, data string) {
+	t.Helper()
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime()
+	}
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime = modTime.Add(time.Second)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func credentialsJSON(keyID string, key *rsa.PrivateKey) string {
+	data, _ := json.Marshal(&credentialsFile{ClientID: "client", ClientSecret: "secret", SigningKeyID: keyID, SigningKey: pemEncode(key)})
+	return string(data)
+}
+
+func TestUnit_EnvCredentialsProvider(t *testing.T) {
+	t.Setenv(EnvClientID, "client")
+	t.Setenv(EnvClientSecret, "secret-1")
+	t.Setenv(EnvSigningKeyID, "key-1")
+	t.Setenv(EnvSigningKey, pemEncode(testKey(t, 0)))
+
+	p := new(EnvCredentialsProvider)
+	first, err := p.Credentials(context.Background())
+	if err != nil {
+		t.Fatalf("Credentials returned error: %v", err)
+	}
+	if first.ClientID != "client" || first.ClientSecret != "secret-1" || first.SigningKeyID != "key-1" || first.Signer == nil {
+		t.Errorf("Credentials returned %+v, want those in the environment", first)
+	}
+
+	t.Setenv(EnvClientSecret, "secret-2")
+	second, err := p.Credentials(context.Background())
+	if err != nil {
+		t.Fatalf("Credentials returned error: %v", err)
+	}
+	if second.ClientSecret != "secret-2" {
+		t.Errorf("Credentials returned secret %v after rotation, want secret-2", second.ClientSecret)
+	}
+	if second.Signer != first.Signer {
+		t.Error("Credentials parsed the unchanged signing key again")
+	}
+	if first.ClientSecret != "secret-1" {
+		t.Error("Credentials modified credentials returned earlier")
+	}
+
+	t.Setenv(EnvSigningKey, "not a key")
+	if _, err := p.Credentials(context.Background()); err == nil {
+		t.Error("Credentials with an invalid signing key did not return error")
+	}
+}
+
+func TestUnit_FileCredentialsProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	writeCredentialsFile(t, path, credentialsJSON("key-1", testKey(t, 0)))
+
+	p, err := NewFileCredentialsProvider(path)
+	if err != nil {
+		t.Fatalf("NewFileCredentialsProvider returned error: %v", err)
+	}
+	first, _ := p.Credentials(context.Background())
+	if first.SigningKeyID != "key-1" || first.ClientID != "client" {
+		t.Errorf("Credentials returned %+v, want those in the file", first)
+	}
+
+	writeCredentialsFile(t, path, credentialsJSON("key-2", testKey(t, 1)))
+	second, _ := p.Credentials(context.Background())
+	if second.SigningKeyID != "key-2" {
+		t.Errorf("Credentials returned key %v after the file changed, want key-2", second.SigningKeyID)
+	}
+	if first.SigningKeyID != "key-1" {
+		t.Error("Credentials modified credentials returned earlier")
+	}
+
+	writeCredentialsFile(t, path, `{"client_id": "cli`)
+	if got, _ := p.Credentials(context.Background()); got != second {
+		t.Errorf("Credentials returned %+v while the file is invalid, want the previous credentials", got)
+	}
+	writeCredentialsFile(t, path, credentialsJSON("key-3", testKey(t, 0)))
+	if got, _ := p.Credentials(context.Background()); got.SigningKeyID != "key-3" {
+		t.Errorf("Credentials returned key %v once the file is valid again, want key-3", got.SigningKeyID)
+	}
+}
+
+func TestUnit_NewFileCredentialsProvider_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewFileCredentialsProvider(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("NewFileCredentialsProvider with a missing file did not return error")
+	}
+
+	path := filepath.Join(dir, "credentials.json")
+	writeCredentialsFile(t, path, `{"client_id": "client"}`)
+	if _, err := NewFileCredentialsProvider(path); err == nil {
+		t.Error("NewFileCredentialsProvider with incomplete credentials did not return error")
+	}
+}
+
+// rotatingCredentials is a CredentialsProvider whose credentials are
+// replaced by rotate.
+type rotatingCredentials struct {
+	current atomic.Pointer[Credentials]
+}
+
+func (r *rotatingCredentials) Credentials(ctx context.Context) (*Credentials, error) {
+	return r.current.Load(), nil
+}
+
+func (r *rotatingCredentials) rotate(keyID string, key *rsa.PrivateKey) {
+	r.current.Store(&Credentials{SigningKeyID: keyID, Signer: NewRSASigner(key)})
+}
+
+func TestUnit_Client_CredentialsProvider_Rotation(t *testing.T) {
+	provider := new(rotatingCredentials)
+	provider.rotate("key-0", testKey(t, 0))
+	client, mux, _, teardown := setupClientWithStubbedApi(WithCredentialsProvider(provider))
+	defer teardown()
+
+	keys := map[string]*rsa.PublicKey{"key-0": &testKey(t, 0).PublicKey, "key-1": &testKey(t, 1).PublicKey}
+	var mu sync.Mutex
+	used := make(map[string]int)
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		// Hold requests in flight while keys are rotated.
+		time.Sleep(time.Millisecond)
+		keyID := verifySignature(t, r, keys)
+		mu.Lock()
+		used[keyID]++
+		mu.Unlock()
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, _, err := client.Accounts.Fetch(context.Background(), "1"); err != nil {
+					t.Errorf("Fetch returned error: %v", err)
+				}
+			}
+		}()
+	}
+	for i := 1; i <= 20; i++ {
+		provider.rotate(fmt.Sprintf("key-%d", i%2), testKey(t, i%2))
+		time.Sleep(time.Millisecond)
+	}
+	wg.Wait()
+
+	if used["key-0"]+used["key-1"] != 80 {
+		t.Errorf("Requests signed with %v, want 80 signed requests", used)
+	}
+}
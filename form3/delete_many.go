@@ -0,0 +1,190 @@
+package form3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// BulkDeleteOptions specifies the accounts to delete with
+// AccountsService.DeleteMany and how. Exactly one of IDs and Filter must be
+// set.
+type BulkDeleteOptions struct {
+	// IDs of the accounts to delete.
+	IDs []string
+
+	// Deletes the accounts matching the filter. An empty filter matches every
+	// account in the organisation.
+	Filter *AccountFilter
+
+	// If true, nothing is deleted, and the summary lists the accounts that
+	// would have been.
+	DryRun bool
+
+	// If set, called with each account before it is deleted, which is
+	// skipped unless Confirm returns true. Calls are made one at a time.
+	Confirm func(*Account) bool
+
+	// Maximum number of deletes in flight at once. Defaults to 8.
+	Concurrency int
+}
+
+// A BulkDeleteSkipReason explains why an account was not deleted.
+type BulkDeleteSkipReason string
+
+// Reasons for skipping accounts in a bulk delete.
+const (
+	SkipAlreadyDeleted BulkDeleteSkipReason = "already deleted"
+	SkipNotConfirmed   BulkDeleteSkipReason = "not confirmed"
+)
+
+// A BulkDeleteSkip is an account that was deliberately not deleted.
+type BulkDeleteSkip struct {
+	ID     string
+	Reason BulkDeleteSkipReason
+}
+
+// A BulkDeleteFailure is an account that could not be deleted.
+type BulkDeleteFailure struct {
+	ID  string
+	Err error
+}
+
+// BulkDeleteSummary is the outcome of AccountsService.DeleteMany. Accounts
+// are listed in the order they were given or listed.
+type BulkDeleteSummary struct {
+	DryRun bool
+
+	// IDs of the accounts deleted or, in a dry run, that would have been.
+	Deleted []string
+	Skipped []BulkDeleteSkip
+	Failed  []BulkDeleteFailure
+}
+
+// deleteOutcome is the result of deleting one account.
+type deleteOutcome struct {
+	id      string
+	deleted bool
+	skip    BulkDeleteSkipReason
+	err     error
+}
+
+// DeleteMany deletes several accounts, fetching their current versions
+// first so that the caller does not need to know them. Accounts that no
+// longer exist are skipped rather than failed, and accounts that cannot be
+// fetched are failed while the others are still deleted.
+//
+// If the options are invalid, the accounts to delete cannot be listed or ctx
+// is done while fetching them, nothing is deleted and only an error is
+// returned. If ctx is done before every account has been deleted, the
+// summary is returned with the context's error, and the accounts not yet
+// deleted are failed with it.
+func (s *AccountsService) DeleteMany(ctx context.Context, opts *BulkDeleteOptions) (*BulkDeleteSummary, error) {
+	if opts == nil || (opts.IDs == nil) == (opts.Filter == nil) {
+		return nil, errors.New("exactly one of IDs and Filter must be given")
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	// Find the current version of each account, recording the outcome for
+	// those that cannot be deleted in order.
+	var outcomes []*deleteOutcome
+	var targets []*Account
+	var targetOutcomes []*deleteOutcome
+	addTarget := func(a *Account) {
+		o := &deleteOutcome{id: stringValue(a.ID)}
+		outcomes = append(outcomes, o)
+		targets = append(targets, a)
+		targetOutcomes = append(targetOutcomes, o)
+	}
+
+	if opts.Filter != nil {
+		err := s.ListAll(ctx, &AccountListOptions{Filter: opts.Filter}, func(a *Account) error {
+			addTarget(a)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		fetched, err := s.FetchMany(ctx, opts.IDs, &FetchManyOptions{Concurrency: concurrency})
+		if err != nil {
+			return nil, err
+		}
+		for i, id := range opts.IDs {
+			switch {
+			case fetched.Accounts[i] != nil:
+				addTarget(fetched.Accounts[i])
+			case fetched.NotFound[id]:
+				outcomes = append(outcomes, &deleteOutcome{id: id, skip: SkipAlreadyDeleted})
+			case fetched.Errors[id] != nil:
+				outcomes = append(outcomes, &deleteOutcome{id: id, err: fetched.Errors[id]})
+			default:
+				outcomes = append(outcomes, &deleteOutcome{id: id, err: errors.New("fetching the account returned no account")})
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	var ctxErr error
+	for i, a := range targets {
+		o := targetOutcomes[i]
+		if err := ctx.Err(); err != nil {
+			ctxErr, o.err = err, err
+			continue
+		}
+		if opts.Confirm != nil && !opts.Confirm(a) {
+			o.skip = SkipNotConfirmed
+			continue
+		}
+		if opts.DryRun {
+			o.deleted = true
+			continue
+		}
+		if a.Version == nil {
+			o.err = errors.New("account has no version")
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			ctxErr, o.err = ctx.Err(), ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(o *deleteOutcome, version int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			_, err := s.Delete(ctx, o.id, version)
+			switch {
+			case err == nil:
+				o.deleted = true
+			case isStatus(err, http.StatusNotFound):
+				o.skip = SkipAlreadyDeleted
+			default:
+				o.err = err
+			}
+		}(o, *a.Version)
+	}
+	wg.Wait()
+
+	summary := &BulkDeleteSummary{DryRun: opts.DryRun}
+	for _, o := range outcomes {
+		switch {
+		case o.deleted:
+			summary.Deleted = append(summary.Deleted, o.id)
+		case o.skip != "":
+			summary.Skipped = append(summary.Skipped, BulkDeleteSkip{ID: o.id, Reason: o.skip})
+		case o.err != nil:
+			summary.Failed = append(summary.Failed, BulkDeleteFailure{ID: o.id, Err: o.err})
+		}
+	}
+	return summary, ctxErr
+}
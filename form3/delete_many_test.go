@@ -0,0 +1,179 @@
+package form3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// serveDeletableAccounts serves fetches and deletes of accounts with the
+// given versions, recording the deletes made. Deleting with the wrong
+// version fails with a 409.
+func serveDeletableAccounts(mux *http.ServeMux, versions map[string]int) (deleted func() []string) {
+	var mu sync.Mutex
+	var deletes []string
+	mux.HandleFunc("/organisation/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		id := strings.TrimPrefix(r.URL.Path, "/organisation/accounts/")
+		version, ok := versions[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case "GET":
+			fmt.Fprintf(w, `{"data": {"id": %q, "version": %d}}`, id, version)
+		case "DELETE":
+			if r.URL.Query().Get("version") != fmt.Sprint(version) {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `{"error_message": "invalid version"}`)
+				return
+			}
+			delete(versions, id)
+			deletes = append(deletes, id)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), deletes...)
+	}
+}
+
+func TestUnit_AccountsService_DeleteMany(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	deleted := serveDeletableAccounts(mux, map[string]int{"a": 0, "b": 3, "c": 1})
+
+	summary, err := client.Accounts.DeleteMany(context.Background(), &BulkDeleteOptions{
+		IDs:     []string{"a", "gone", "b", "c"},
+		Confirm: func(a *Account) bool { return *a.ID != "c" },
+	})
+	if err != nil {
+		t.Fatalf("DeleteMany returned error: %v", err)
+	}
+
+	if want := []string{"a", "b"}; !reflect.DeepEqual(summary.Deleted, want) {
+		t.Errorf("Deleted = %v, want %v", summary.Deleted, want)
+	}
+	wantSkipped := []BulkDeleteSkip{{ID: "gone", Reason: SkipAlreadyDeleted}, {ID: "c", Reason: SkipNotConfirmed}}
+	if !reflect.DeepEqual(summary.Skipped, wantSkipped) {
+		t.Errorf("Skipped = %v, want %v", summary.Skipped, wantSkipped)
+	}
+	if len(summary.Failed) != 0 {
+		t.Errorf("Failed = %v, want none", summary.Failed)
+	}
+
+	got := deleted()
+	if len(got) != 2 {
+		t.Errorf("Server deleted %v, want a and b", got)
+	}
+}
+
+func TestUnit_AccountsService_DeleteMany_NoAccountFetched(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	deleted := serveDeletableAccounts(mux, map[string]int{"a": 0})
+	mux.HandleFunc("/organisation/accounts/empty", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": null}`)
+	})
+
+	summary, err := client.Accounts.DeleteMany(context.Background(), &BulkDeleteOptions{IDs: []string{"empty", "a"}})
+	if err != nil {
+		t.Fatalf("DeleteMany returned error: %v", err)
+	}
+	if len(summary.Failed) != 1 || summary.Failed[0].ID != "empty" || summary.Failed[0].Err == nil {
+		t.Errorf("Failed = %v, want empty with an error", summary.Failed)
+	}
+	if got := deleted(); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("Server deleted %v, want [a]", got)
+	}
+}
+
+func TestUnit_AccountsService_DeleteMany_Cancelled(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	deleted := serveDeletableAccounts(mux, map[string]int{"a": 0, "b": 1, "c": 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	summary, err := client.Accounts.DeleteMany(ctx, &BulkDeleteOptions{
+		IDs: []string{"a", "b", "c"},
+		Confirm: func(a *Account) bool {
+			cancel()
+			return false
+		},
+	})
+	if err != context.Canceled {
+		t.Errorf("DeleteMany returned error %v, want %v", err, context.Canceled)
+	}
+	if want := []BulkDeleteSkip{{ID: "a", Reason: SkipNotConfirmed}}; !reflect.DeepEqual(summary.Skipped, want) {
+		t.Errorf("Skipped = %v, want %v", summary.Skipped, want)
+	}
+	want := []BulkDeleteFailure{{ID: "b", Err: context.Canceled}, {ID: "c", Err: context.Canceled}}
+	if !reflect.DeepEqual(summary.Failed, want) {
+		t.Errorf("Failed = %v, want the accounts not yet deleted failed with %v", summary.Failed, context.Canceled)
+	}
+	if got := deleted(); len(got) != 0 {
+		t.Errorf("Server deleted %v after cancellation", got)
+	}
+}
+
+func TestUnit_AccountsService_DeleteMany_DryRun(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	deleted := serveDeletableAccounts(mux, map[string]int{"a": 0, "b": 3})
+
+	summary, err := client.Accounts.DeleteMany(context.Background(), &BulkDeleteOptions{IDs: []string{"a", "b"}, DryRun: true})
+	if err != nil {
+		t.Fatalf("DeleteMany returned error: %v", err)
+	}
+	if !summary.DryRun || !reflect.DeepEqual(summary.Deleted, []string{"a", "b"}) {
+		t.Errorf("Summary = %+v, want a and b to be deleted in a dry run", summary)
+	}
+	if got := deleted(); len(got) != 0 {
+		t.Errorf("Server deleted %v in a dry run", got)
+	}
+}
+
+func TestUnit_AccountsService_DeleteMany_Filter(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	accounts := []*Account{
+		{ID: String("a"), Version: Int(0), Attributes: &AccountAttributes{CustomerId: String("c1")}},
+		{ID: String("b"), Version: Int(2), Attributes: &AccountAttributes{CustomerId: String("c1")}},
+	}
+	serveAccounts(t, mux, accounts, true)
+	deleted := serveDeletableAccounts(mux, map[string]int{"a": 0, "b": 1})
+
+	summary, err := client.Accounts.DeleteMany(context.Background(), &BulkDeleteOptions{Filter: &AccountFilter{CustomerID: "c1"}})
+	if err != nil {
+		t.Fatalf("DeleteMany returned error: %v", err)
+	}
+	if !reflect.DeepEqual(summary.Deleted, []string{"a"}) {
+		t.Errorf("Deleted = %v, want [a]", summary.Deleted)
+	}
+	if len(summary.Failed) != 1 || summary.Failed[0].ID != "b" || !isStatus(summary.Failed[0].Err, http.StatusConflict) {
+		t.Errorf("Failed = %v, want b with a version conflict", summary.Failed)
+	}
+	if got := deleted(); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("Server deleted %v, want [a]", got)
+	}
+}
+
+func TestUnit_AccountsService_DeleteMany_InvalidOptions(t *testing.T) {
+	client := NewClient(nil)
+
+	for _, opts := range []*BulkDeleteOptions{nil, {}, {IDs: []string{"a"}, Filter: &AccountFilter{}}} {
+		if _, err := client.Accounts.DeleteMany(context.Background(), opts); err == nil {
+			t.Errorf("DeleteMany(%+v) did not return error", opts)
+		}
+	}
+}
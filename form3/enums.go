@@ -0,0 +1,138 @@
+package form3
+
+import "fmt"
+
+// The enumerated types below are encoded as JSON strings. Any value is
+// accepted when decoding and encoded as is, so that values added to the API
+// later do not break responses or stop accounts read from the API being
+// written again. Accounts sent to be created or updated are checked by
+// checkEnums instead, so that typos are caught before a request is sent.
+
+// A Country is an ISO 3166-1 alpha-2 country code of a country supported by
+// Form3 accounts.
+type Country string
+
+// Countries supported by Form3 accounts.
+const (
+	CountryAU Country = "AU" // Australia
+	CountryBE Country = "BE" // Belgium
+	CountryCA Country = "CA" // Canada
+	CountryCH Country = "CH" // Switzerland
+	CountryDE Country = "DE" // Germany
+	CountryES Country = "ES" // Spain
+	CountryFR Country = "FR" // France
+	CountryGB Country = "GB" // United Kingdom
+	CountryGR Country = "GR" // Greece
+	CountryHK Country = "HK" // Hong Kong
+	CountryIE Country = "IE" // Ireland
+	CountryIT Country = "IT" // Italy
+	CountryLU Country = "LU" // Luxembourg
+	CountryNL Country = "NL" // Netherlands
+	CountryPL Country = "PL" // Poland
+	CountryPT Country = "PT" // Portugal
+	CountryUS Country = "US" // United States
+)
+
+var countries = map[Country]bool{
+	CountryAU: true, CountryBE: true, CountryCA: true, CountryCH: true, CountryDE: true,
+	CountryES: true, CountryFR: true, CountryGB: true, CountryGR: true, CountryHK: true,
+	CountryIE: true, CountryIT: true, CountryLU: true, CountryNL: true, CountryPL: true,
+	CountryPT: true, CountryUS: true,
+}
+
+// IsValid reports whether c is a country supported by Form3 accounts.
+func (c Country) IsValid() bool { return countries[c] }
+
+// Ptr returns a pointer to a copy of c.
+func (c Country) Ptr() *Country { return &c }
+
+// A BankIdCode identifies the type of bank ID of an account. Each country
+// has its own code.
+type BankIdCode string
+
+// Bank ID codes accepted by Form3.
+const (
+	BankIdCodeAUBSB BankIdCode = "AUBSB" // Australia: BSB code
+	BankIdCodeBE    BankIdCode = "BE"    // Belgium
+	BankIdCodeCACPA BankIdCode = "CACPA" // Canada: routing number
+	BankIdCodeCHBCC BankIdCode = "CHBCC" // Switzerland: BC-Nummer
+	BankIdCodeDEBLZ BankIdCode = "DEBLZ" // Germany: Bankleitzahl
+	BankIdCodeESNCC BankIdCode = "ESNCC" // Spain
+	BankIdCodeFR    BankIdCode = "FR"    // France
+	BankIdCodeGBDSC BankIdCode = "GBDSC" // United Kingdom: sort code
+	BankIdCodeGRBIC BankIdCode = "GRBIC" // Greece: HEBIC
+	BankIdCodeHKNCC BankIdCode = "HKNCC" // Hong Kong
+	BankIdCodeITNCC BankIdCode = "ITNCC" // Italy
+	BankIdCodeLULUX BankIdCode = "LULUX" // Luxembourg
+	BankIdCodePLKNR BankIdCode = "PLKNR" // Poland: KNR
+	BankIdCodePTNCC BankIdCode = "PTNCC" // Portugal
+	BankIdCodeUSABA BankIdCode = "USABA" // United States: ABA routing number
+)
+
+var bankIdCodes = map[BankIdCode]bool{
+	BankIdCodeAUBSB: true, BankIdCodeBE: true, BankIdCodeCACPA: true, BankIdCodeCHBCC: true,
+	BankIdCodeDEBLZ: true, BankIdCodeESNCC: true, BankIdCodeFR: true, BankIdCodeGBDSC: true,
+	BankIdCodeGRBIC: true, BankIdCodeHKNCC: true, BankIdCodeITNCC: true, BankIdCodeLULUX: true,
+	BankIdCodePLKNR: true, BankIdCodePTNCC: true, BankIdCodeUSABA: true,
+}
+
+// IsValid reports whether c is a bank ID code accepted by Form3.
+func (c BankIdCode) IsValid() bool { return bankIdCodes[c] }
+
+// Ptr returns a pointer to a copy of c.
+func (c BankIdCode) Ptr() *BankIdCode { return &c }
+
+// An AccountClassification classifies an account for Confirmation of Payee.
+type AccountClassification string
+
+// Account classifications.
+const (
+	ClassificationPersonal AccountClassification = "Personal"
+	ClassificationBusiness AccountClassification = "Business"
+)
+
+// IsValid reports whether c is a known account classification.
+func (c AccountClassification) IsValid() bool {
+	return c == ClassificationPersonal || c == ClassificationBusiness
+}
+
+// Ptr returns a pointer to a copy of c.
+func (c AccountClassification) Ptr() *AccountClassification { return &c }
+
+// An AccountStatus is the status of an account.
+type AccountStatus string
+
+// Account statuses.
+const (
+	StatusPending   AccountStatus = "pending"
+	StatusConfirmed AccountStatus = "confirmed"
+	StatusFailed    AccountStatus = "failed"
+)
+
+// IsValid reports whether s is a known account status.
+func (s AccountStatus) IsValid() bool {
+	return s == StatusPending || s == StatusConfirmed || s == StatusFailed
+}
+
+// Ptr returns a pointer to a copy of s.
+func (s AccountStatus) Ptr() *AccountStatus { return &s }
+
+// checkEnums returns an error if an enumerated attribute of account is set
+// to a value that is not valid. Bank ID codes are not checked, as the API
+// accepts codes beyond those documented.
+func checkEnums(account *Account) error {
+	a := account.Attributes
+	switch {
+	case a == nil:
+		return nil
+	case a.Country != nil && !a.Country.IsValid():
+		return fmt.Errorf("invalid country %q", *a.Country)
+	case a.BaseCurrency != nil && !a.BaseCurrency.IsValid():
+		return fmt.Errorf("invalid currency %q", *a.BaseCurrency)
+	case a.AccountClassification != nil && !a.AccountClassification.IsValid():
+		return fmt.Errorf("invalid account classification %q", *a.AccountClassification)
+	case a.Status != nil && !a.Status.IsValid():
+		return fmt.Errorf("invalid account status %q", *a.Status)
+	}
+	return nil
+}
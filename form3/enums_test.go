@@ -0,0 +1,108 @@
+package form3
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUnit_Enums_IsValid(t *testing.T) {
+	valid := []interface{ IsValid() bool }{
+		CountryGB, CountryDE, CurrencyGBP, Currency("JPY"), BankIdCodeGBDSC, BankIdCodeFR,
+		ClassificationPersonal, ClassificationBusiness, StatusPending, StatusConfirmed, StatusFailed,
+	}
+	for _, v := range valid {
+		if !v.IsValid() {
+			t.Errorf("%v.IsValid() = false, want true", v)
+		}
+	}
+
+	invalid := []interface{ IsValid() bool }{
+		Country("UK"), Country("gb"), Currency("GPB"), BankIdCode("GBDXN"),
+		AccountClassification("personal"), AccountStatus("Confirmed"), Country(""),
+	}
+	for _, v := range invalid {
+		if v.IsValid() {
+			t.Errorf("%q.IsValid() = true, want false", v)
+		}
+	}
+}
+
+func TestUnit_Enums_Marshal(t *testing.T) {
+	attributes := &AccountAttributes{
+		Country:               CountryGB.Ptr(),
+		BaseCurrency:          CurrencyGBP.Ptr(),
+		BankIdCode:            BankIdCodeGBDSC.Ptr(),
+		AccountClassification: ClassificationBusiness.Ptr(),
+		Status:                StatusConfirmed.Ptr(),
+	}
+	data, err := json.Marshal(attributes)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	want := `{"country":"GB","base_currency":"GBP","bank_id_code":"GBDSC","name":null,"account_classification":"Business","status":"confirmed"}`
+	if string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+
+}
+
+func TestUnit_Enums_RoundTripUnknown(t *testing.T) {
+	data := []byte(`{"country":"SE","bank_id_code":"SESBA","name":null,"account_classification":"Charity","status":"closed"}`)
+	attributes := new(AccountAttributes)
+	if err := json.Unmarshal(data, attributes); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	got, err := json.Marshal(attributes)
+	if err != nil {
+		t.Fatalf("Marshal of unknown values returned error: %v", err)
+	}
+	if string(got) != string(data) {
+		t.Errorf("Marshal = %s, want %s", got, data)
+	}
+}
+
+func TestUnit_Enums_UnmarshalUnknown(t *testing.T) {
+	attributes := new(AccountAttributes)
+	err := json.Unmarshal([]byte(`{"country":"ZZ","bank_id_code":"ZZBANK","status":"closed"}`), attributes)
+	if err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if *attributes.Country != "ZZ" || *attributes.BankIdCode != "ZZBANK" || *attributes.Status != "closed" {
+		t.Errorf("Unmarshal = %+v, want unknown values kept", attributes)
+	}
+}
+
+func TestUnit_Enums_CreateRejectsInvalid(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Request sent for account with invalid attribute")
+	})
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Request sent for account with invalid attribute")
+	})
+
+	tests := []struct {
+		attributes *AccountAttributes
+		want       string
+	}{
+		{&AccountAttributes{Country: Country("UK").Ptr()}, `invalid country "UK"`},
+		{&AccountAttributes{BaseCurrency: Currency("GPB").Ptr()}, `invalid currency "GPB"`},
+		{&AccountAttributes{AccountClassification: AccountClassification("Charity").Ptr()}, `invalid account classification "Charity"`},
+		{&AccountAttributes{Status: AccountStatus("open").Ptr()}, `invalid account status "open"`},
+	}
+	for _, tt := range tests {
+		_, _, err := client.Accounts.Create(context.Background(), &Account{Attributes: tt.attributes})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Create returned error %v, want %s", err, tt.want)
+		}
+		_, _, err = client.Accounts.Update(context.Background(), &Account{ID: String("1"), Version: Int(0), Attributes: tt.attributes})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Update returned error %v, want %s", err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package form3
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// defaultConcurrency is the number of requests made at once by bulk
+// operations that do not specify one.
+const defaultConcurrency = 8
+
+// FetchManyOptions specifies the optional parameters to
+// AccountsService.FetchMany.
+type FetchManyOptions struct {
+	// Maximum number of fetches in flight at once. Defaults to 8.
+	Concurrency int
+
+	// If true, outstanding fetches are cancelled as soon as one fails with
+	// an error other than not found, and that error is returned.
+	FailFast bool
+}
+
+// FetchManyResult is the result of AccountsService.FetchMany.
+type FetchManyResult struct {
+	// Accounts in the order their IDs were given, nil where the account was
+	// not found or could not be fetched.
+	Accounts []*Account
+
+	// IDs of accounts that do not exist.
+	NotFound map[string]bool
+
+	// Errors of fetches that failed for reasons other than the account not
+	// existing, by account ID.
+	Errors map[string]error
+}
+
+// FetchMany fetches the accounts with the given IDs, making up to
+// opts.Concurrency requests at once. Missing accounts and failed fetches are
+// reported in the result rather than as an error. An error is returned if ctx
+// is done before every account is fetched, or if a fetch fails in fail-fast
+// mode; the partial result is returned with it.
+func (s *AccountsService) FetchMany(ctx context.Context, ids []string, opts *FetchManyOptions) (*FetchManyResult, error) {
+	o := FetchManyOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultConcurrency
+	}
+
+	result := &FetchManyResult{
+		Accounts: make([]*Account, len(ids)),
+		NotFound: make(map[string]bool),
+		Errors:   make(map[string]error),
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	sem := make(chan struct{}, o.Concurrency)
+
+loop:
+	for i, id := range ids {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			details, _, err := s.Fetch(ctx, id)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				result.Accounts[i] = details.Data
+			case isStatus(err, http.StatusNotFound):
+				result.NotFound[id] = true
+			case ctx.Err() != nil:
+				// Cancelled: reported once below rather than per ID.
+			default:
+				result.Errors[id] = err
+				if o.FailFast && firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+			}
+		}(i, id)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return result, firstErr
+	}
+	return result, ctx.Err()
+}
@@ -0,0 +1,118 @@
+package form3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUnit_AccountsService_FetchMany(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	var inFlight, maxInFlight int32
+	mux.HandleFunc("/organisation/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		id := strings.TrimPrefix(r.URL.Path, "/organisation/accounts/")
+		switch {
+		case strings.HasPrefix(id, "missing"):
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasPrefix(id, "broken"):
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			fmt.Fprintf(w, `{"data": {"id": %q}}`, id)
+		}
+	})
+
+	ids := []string{"a", "missing-1", "b", "broken-1", "c", "d", "e", "f"}
+	result, err := client.Accounts.FetchMany(context.Background(), ids, &FetchManyOptions{Concurrency: 3})
+	if err != nil {
+		t.Fatalf("FetchMany returned error: %v", err)
+	}
+
+	for i, id := range ids {
+		account := result.Accounts[i]
+		if strings.Contains(id, "-") {
+			if account != nil {
+				t.Errorf("Accounts[%d] = %v, want nil", i, *account.ID)
+			}
+		} else if account == nil || *account.ID != id {
+			t.Errorf("Accounts[%d] = %v, want %v", i, account, id)
+		}
+	}
+	if len(result.NotFound) != 1 || !result.NotFound["missing-1"] {
+		t.Errorf("NotFound = %v, want missing-1", result.NotFound)
+	}
+	if len(result.Errors) != 1 || result.Errors["broken-1"] == nil {
+		t.Errorf("Errors = %v, want broken-1", result.Errors)
+	}
+	if maxInFlight > 3 {
+		t.Errorf("%d fetches in flight, want at most 3", maxInFlight)
+	}
+}
+
+func TestUnit_AccountsService_FetchMany_FailFast(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	var mu sync.Mutex
+	fetched := 0
+	mux.HandleFunc("/organisation/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched++
+		mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/broken") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+
+	ids := []string{"broken"}
+	for i := 0; i < 50; i++ {
+		ids = append(ids, fmt.Sprint(i))
+	}
+	result, err := client.Accounts.FetchMany(context.Background(), ids, &FetchManyOptions{Concurrency: 1, FailFast: true})
+	if errorResponse, ok := err.(*ErrorResponse); !ok || errorResponse.Response.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("FetchMany returned error %v, want 500 error response", err)
+	}
+	if result.Errors["broken"] == nil {
+		t.Errorf("Errors = %v, want broken", result.Errors)
+	}
+	if fetched > 2 {
+		t.Errorf("%d accounts fetched after failure, want fetching to stop", fetched-1)
+	}
+}
+
+func TestUnit_AccountsService_FetchMany_Cancelled(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mux.HandleFunc("/organisation/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-r.Context().Done()
+	})
+
+	result, err := client.Accounts.FetchMany(ctx, []string{"a", "b", "c"}, &FetchManyOptions{Concurrency: 1})
+	if err != context.Canceled {
+		t.Errorf("FetchMany returned error %v, want %v", err, context.Canceled)
+	}
+	if len(result.Errors) != 0 {
+		t.Errorf("Errors = %v, want cancellation reported once", result.Errors)
+	}
+}
@@ -0,0 +1,117 @@
+package form3
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Fields is a JSON:API sparse fieldset, restricting the attributes returned
+// for resources of one type to those listed. Resources decoded from a
+// response requested with a fieldset are only partially populated: the
+// attributes not listed are left unset.
+//
+// JSON:API docs: https://jsonapi.org/format/#fetching-sparse-fieldsets
+type Fields[T ~string] []T
+
+// EncodeValues sets key to the comma separated fields, implementing
+// query.Encoder.
+func (f Fields[T]) EncodeValues(key string, v *url.Values) error {
+	if len(f) > 0 {
+		v.Set(key, joinStrings(f))
+	}
+	return nil
+}
+
+// Include lists the relationships whose related resources are included in
+// a response, as a compound document.
+//
+// JSON:API docs: https://jsonapi.org/format/#fetching-includes
+type Include[T ~string] []T
+
+// EncodeValues sets key to the comma separated relationships, implementing
+// query.Encoder.
+func (inc Include[T]) EncodeValues(key string, v *url.Values) error {
+	if len(inc) > 0 {
+		v.Set(key, joinStrings(inc))
+	}
+	return nil
+}
+
+// A SortKey orders resources by one field.
+type SortKey[T ~string] struct {
+	Field      T
+	Descending bool
+}
+
+// Asc returns a SortKey ordering by field in ascending order.
+func Asc[T ~string](field T) SortKey[T] {
+	return SortKey[T]{Field: field}
+}
+
+// Desc returns a SortKey ordering by field in descending order.
+func Desc[T ~string](field T) SortKey[T] {
+	return SortKey[T]{Field: field, Descending: true}
+}
+
+// Sort orders a list of resources by each key in turn.
+//
+// JSON:API docs: https://jsonapi.org/format/#fetching-sorting
+type Sort[T ~string] []SortKey[T]
+
+// EncodeValues sets key to the comma separated sort fields, prefixing
+// descending fields with a minus sign, implementing query.Encoder.
+func (s Sort[T]) EncodeValues(key string, v *url.Values) error {
+	if len(s) == 0 {
+		return nil
+	}
+	fields := make([]string, len(s))
+	for i, k := range s {
+		fields[i] = string(k.Field)
+		if k.Descending {
+			fields[i] = "-" + fields[i]
+		}
+	}
+	v.Set(key, strings.Join(fields, ","))
+	return nil
+}
+
+func joinStrings[T ~string](values []T) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = string(v)
+	}
+	return strings.Join(s, ",")
+}
+
+// An AccountField names an account attribute, to select in a sparse
+// fieldset or sort by.
+type AccountField string
+
+// Account attributes.
+const (
+	AccountFieldCountry                 AccountField = "country"
+	AccountFieldBaseCurrency            AccountField = "base_currency"
+	AccountFieldAccountNumber           AccountField = "account_number"
+	AccountFieldBankID                  AccountField = "bank_id"
+	AccountFieldBankIdCode              AccountField = "bank_id_code"
+	AccountFieldBIC                     AccountField = "bic"
+	AccountFieldIBAN                    AccountField = "iban"
+	AccountFieldCustomerID              AccountField = "customer_id"
+	AccountFieldName                    AccountField = "name"
+	AccountFieldAlternativeNames        AccountField = "alternative_names"
+	AccountFieldAccountClassification   AccountField = "account_classification"
+	AccountFieldJointAccount            AccountField = "joint_account"
+	AccountFieldAccountMatchingOptOut   AccountField = "account_matching_opt_out"
+	AccountFieldSecondaryIdentification AccountField = "secondary_identification"
+	AccountFieldSwitched                AccountField = "switched"
+	AccountFieldStatus                  AccountField = "status"
+)
+
+// An AccountRelationship names a relationship of an account, to include
+// the related resources of.
+type AccountRelationship string
+
+// Account relationships.
+const (
+	AccountRelationshipMasterAccount AccountRelationship = "master_account"
+)
@@ -0,0 +1,26 @@
+package form3
+
+import (
+	"testing"
+)
+
+func TestUnit_addOptions_JSONAPIParameters(t *testing.T) {
+	tests := []struct {
+		opts *AccountListOptions
+		want string
+	}{
+		{&AccountListOptions{}, "organisation/accounts"},
+		{&AccountListOptions{Fields: Fields[AccountField]{AccountFieldName}}, "organisation/accounts?fields%5Baccounts%5D=name"},
+		{&AccountListOptions{Include: Include[AccountRelationship]{AccountRelationshipMasterAccount}}, "organisation/accounts?include=master_account"},
+		{&AccountListOptions{Sort: Sort[AccountField]{Desc(AccountFieldStatus), Asc(AccountFieldBankID)}}, "organisation/accounts?sort=-status%2Cbank_id"},
+	}
+	for _, tt := range tests {
+		got, err := addOptions("organisation/accounts", tt.opts)
+		if err != nil {
+			t.Fatalf("addOptions returned error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("addOptions = %v, want %v", got, tt.want)
+		}
+	}
+}
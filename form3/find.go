@@ -0,0 +1,163 @@
+package form3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultPageSize is the page size used when paging through all accounts.
+const defaultPageSize = 100
+
+// An AccountNotFoundError is returned when no account matches a search.
+type AccountNotFoundError struct {
+	Criteria string // description of what was searched for, e.g. "iban GB11NWBK40030041426819"
+}
+
+func (e *AccountNotFoundError) Error() string {
+	return fmt.Sprintf("no account found with %s", e.Criteria)
+}
+
+// A MultipleAccountsError is returned when several accounts match a search
+// that should identify a single account.
+type MultipleAccountsError struct {
+	Criteria string
+	Accounts []*Account
+}
+
+func (e *MultipleAccountsError) Error() string {
+	return fmt.Sprintf("%d accounts found with %s", len(e.Accounts), e.Criteria)
+}
+
+// ListAll calls fn for every account matching opts, requesting pages of
+// results until there are no more. Paging starts at the page number in opts
+// and uses its page size, or 100 if none is set. If fn returns an error,
+// paging stops and the error is returned.
+func (s *AccountsService) ListAll(ctx context.Context, opts *AccountListOptions, fn func(*Account) error) error {
+	o := AccountListOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.PageSize <= 0 {
+		o.PageSize = defaultPageSize
+	}
+
+	for {
+		page, _, err := s.ListWithOptions(ctx, &o)
+		if err != nil {
+			return err
+		}
+		for _, account := range page.Data {
+			if err := fn(account); err != nil {
+				return err
+			}
+		}
+		if isLastPage(page, o.PageSize) {
+			return nil
+		}
+		o.PageNumber++
+	}
+}
+
+// isLastPage reports whether page is the last page of a listing with the
+// given page size. The next link is followed whenever the response has
+// links, as the API may return fewer results than asked for; otherwise a
+// short page is taken to be the last.
+func isLastPage(page *AccountDetailsListResponse, pageSize int) bool {
+	if page.Links != nil {
+		return page.Links.Next == nil || len(page.Data) == 0
+	}
+	return len(page.Data) < pageSize
+}
+
+// FindByIBAN returns the account with the given IBAN, which may contain
+// spaces. It returns an *AccountNotFoundError if there is none and a
+// *MultipleAccountsError if there are several.
+func (s *AccountsService) FindByIBAN(ctx context.Context, iban string) (*Account, error) {
+	iban = normaliseIBAN(iban)
+	matches, err := s.find(ctx, &AccountFilter{IBAN: iban}, func(a *Account) bool {
+		return a.Attributes != nil && normaliseIBAN(stringValue(a.Attributes.IBAN)) == iban
+	})
+	if err != nil {
+		return nil, err
+	}
+	return single(matches, "iban "+iban)
+}
+
+// FindByAccountNumber returns the account with the given bank ID and account
+// number. It returns an *AccountNotFoundError if there is none and a
+// *MultipleAccountsError if there are several.
+func (s *AccountsService) FindByAccountNumber(ctx context.Context, bankID, accountNumber string) (*Account, error) {
+	matches, err := s.find(ctx, &AccountFilter{BankID: bankID, AccountNumber: accountNumber}, func(a *Account) bool {
+		return a.Attributes != nil &&
+			stringValue(a.Attributes.BankId) == bankID &&
+			stringValue(a.Attributes.AccountNumber) == accountNumber
+	})
+	if err != nil {
+		return nil, err
+	}
+	return single(matches, fmt.Sprintf("bank_id %s and account_number %s", bankID, accountNumber))
+}
+
+// FindByCustomerID returns all accounts with the given customer ID. It
+// returns an *AccountNotFoundError if there are none.
+func (s *AccountsService) FindByCustomerID(ctx context.Context, customerID string) ([]*Account, error) {
+	matches, err := s.find(ctx, &AccountFilter{CustomerID: customerID}, func(a *Account) bool {
+		return a.Attributes != nil && stringValue(a.Attributes.CustomerId) == customerID
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, &AccountNotFoundError{Criteria: "customer_id " + customerID}
+	}
+	return matches, nil
+}
+
+// find returns the accounts for which match returns true. It lists accounts
+// with filter, checking each result with match in case the filter is
+// ignored, and falls back to scanning every account if the API rejects the
+// filter.
+func (s *AccountsService) find(ctx context.Context, filter *AccountFilter, match func(*Account) bool) ([]*Account, error) {
+	var matches []*Account
+	collect := func(a *Account) error {
+		if match(a) {
+			matches = append(matches, a)
+		}
+		return nil
+	}
+
+	err := s.ListAll(ctx, &AccountListOptions{Filter: filter}, collect)
+	if isStatus(err, http.StatusBadRequest) {
+		matches = nil
+		err = s.ListAll(ctx, nil, collect)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+func single(matches []*Account, criteria string) (*Account, error) {
+	switch len(matches) {
+	case 0:
+		return nil, &AccountNotFoundError{Criteria: criteria}
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, &MultipleAccountsError{Criteria: criteria, Accounts: matches}
+	}
+}
+
+func normaliseIBAN(iban string) string {
+	return strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+}
+
+// isStatus reports whether err is an API error response with the given
+// status code.
+func isStatus(err error, status int) bool {
+	var errorResponse *ErrorResponse
+	return errors.As(err, &errorResponse) && errorResponse.Response != nil && errorResponse.Response.StatusCode == status
+}
@@ -0,0 +1,216 @@
+package form3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+var findTestAccounts = []*Account{
+	{ID: String("1"), Attributes: &AccountAttributes{BankId: String("400300"), AccountNumber: String("41426819"), IBAN: String("GB11NWBK40030041426819"), CustomerId: String("c1")}},
+	{ID: String("2"), Attributes: &AccountAttributes{BankId: String("400300"), AccountNumber: String("41426820"), IBAN: String("GB84NWBK40030041426820"), CustomerId: String("c1")}},
+	{ID: String("3"), Attributes: &AccountAttributes{BankId: String("400301"), AccountNumber: String("41426819"), CustomerId: String("c2")}},
+	{ID: String("4"), Attributes: &AccountAttributes{BankId: String("400300"), AccountNumber: String("41426819"), CustomerId: String("c3")}},
+	{ID: String("5"), Attributes: &AccountAttributes{}},
+}
+
+// serveAccounts serves accounts from the organisation/accounts endpoint with
+// paging. Filters are applied if supportFilters is true, and rejected with a
+// 400 otherwise. It returns a pointer to the number of requests served.
+func serveAccounts(t *testing.T, mux *http.ServeMux, accounts []*Account, supportFilters bool) *int {
+	t.Helper()
+	requests := 0
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		q := r.URL.Query()
+		matching := accounts
+		filters := map[string]func(*Account) string{
+			"filter[iban]":           func(a *Account) string { return stringValue(a.Attributes.IBAN) },
+			"filter[bank_id]":        func(a *Account) string { return stringValue(a.Attributes.BankId) },
+			"filter[account_number]": func(a *Account) string { return stringValue(a.Attributes.AccountNumber) },
+			"filter[customer_id]":    func(a *Account) string { return stringValue(a.Attributes.CustomerId) },
+		}
+		for key, value := range filters {
+			want := q.Get(key)
+			if want == "" {
+				continue
+			}
+			if !supportFilters {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error_message": "unsupported filter"}`))
+				return
+			}
+			var filtered []*Account
+			for _, a := range matching {
+				if value(a) == want {
+					filtered = append(filtered, a)
+				}
+			}
+			matching = filtered
+		}
+
+		number, _ := strconv.Atoi(q.Get("page[number]"))
+		size, _ := strconv.Atoi(q.Get("page[size]"))
+		start, end := number*size, (number+1)*size
+		if start > len(matching) {
+			start = len(matching)
+		}
+		if end > len(matching) {
+			end = len(matching)
+		}
+		json.NewEncoder(w).Encode(&AccountDetailsListResponse{Data: matching[start:end]})
+	})
+	return &requests
+}
+
+func TestUnit_AccountsService_ListAll(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	requests := serveAccounts(t, mux, findTestAccounts, true)
+
+	var ids []string
+	err := client.Accounts.ListAll(context.Background(), &AccountListOptions{ListOptions: ListOptions{PageSize: 2}}, func(a *Account) error {
+		ids = append(ids, *a.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ListAll returned error: %v", err)
+	}
+	if len(ids) != len(findTestAccounts) {
+		t.Errorf("ListAll returned %v, want all %d accounts", ids, len(findTestAccounts))
+	}
+	if *requests != 3 {
+		t.Errorf("ListAll made %d requests, want 3", *requests)
+	}
+}
+
+// serveCappedAccounts serves accounts from the organisation/accounts
+// endpoint in pages of at most two, whatever page size is asked for, with a
+// next link on every page but the last.
+func serveCappedAccounts(mux *http.ServeMux, accounts []*Account) {
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		number, _ := strconv.Atoi(r.URL.Query().Get("page[number]"))
+		start, end := number*2, number*2+2
+		if start > len(accounts) {
+			start = len(accounts)
+		}
+		if end > len(accounts) {
+			end = len(accounts)
+		}
+		links := &Links{Self: String(r.URL.String())}
+		if end < len(accounts) {
+			links.Next = String(fmt.Sprintf("/v1/organisation/accounts?page[number]=%d", number+1))
+		}
+		json.NewEncoder(w).Encode(&AccountDetailsListResponse{Data: accounts[start:end], Links: links})
+	})
+}
+
+func TestUnit_AccountsService_ListAll_CappedPageSize(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	serveCappedAccounts(mux, findTestAccounts)
+
+	count := 0
+	err := client.Accounts.ListAll(context.Background(), &AccountListOptions{ListOptions: ListOptions{PageSize: 3}}, func(a *Account) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ListAll returned error: %v", err)
+	}
+	if count != len(findTestAccounts) {
+		t.Errorf("ListAll returned %d accounts from pages shorter than asked for, want all %d", count, len(findTestAccounts))
+	}
+}
+
+func TestUnit_AccountsService_FindByIBAN(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	requests := serveAccounts(t, mux, findTestAccounts, true)
+
+	account, err := client.Accounts.FindByIBAN(context.Background(), "GB84 NWBK 4003 0041 4268 20")
+	if err != nil {
+		t.Fatalf("FindByIBAN returned error: %v", err)
+	}
+	if got, want := *account.ID, "2"; got != want {
+		t.Errorf("FindByIBAN returned account %v, want %v", got, want)
+	}
+	if *requests != 1 {
+		t.Errorf("FindByIBAN made %d requests, want 1", *requests)
+	}
+
+	_, err = client.Accounts.FindByIBAN(context.Background(), "GB29NWBK60161331926819")
+	if _, ok := err.(*AccountNotFoundError); !ok {
+		t.Errorf("FindByIBAN returned error %v, want *AccountNotFoundError", err)
+	}
+}
+
+func TestUnit_AccountsService_FindByAccountNumber(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	serveAccounts(t, mux, findTestAccounts, true)
+
+	account, err := client.Accounts.FindByAccountNumber(context.Background(), "400301", "41426819")
+	if err != nil {
+		t.Fatalf("FindByAccountNumber returned error: %v", err)
+	}
+	if got, want := *account.ID, "3"; got != want {
+		t.Errorf("FindByAccountNumber returned account %v, want %v", got, want)
+	}
+
+	_, err = client.Accounts.FindByAccountNumber(context.Background(), "400300", "41426819")
+	multiple, ok := err.(*MultipleAccountsError)
+	if !ok {
+		t.Fatalf("FindByAccountNumber returned error %v, want *MultipleAccountsError", err)
+	}
+	if len(multiple.Accounts) != 2 {
+		t.Errorf("MultipleAccountsError has %d accounts, want 2", len(multiple.Accounts))
+	}
+}
+
+func TestUnit_AccountsService_FindByCustomerID_FallsBack(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	requests := serveAccounts(t, mux, findTestAccounts, false)
+
+	accounts, err := client.Accounts.FindByCustomerID(context.Background(), "c1")
+	if err != nil {
+		t.Fatalf("FindByCustomerID returned error: %v", err)
+	}
+	if len(accounts) != 2 || *accounts[0].ID != "1" || *accounts[1].ID != "2" {
+		t.Errorf("FindByCustomerID returned %d accounts, want 1 and 2", len(accounts))
+	}
+	if *requests != 2 {
+		t.Errorf("FindByCustomerID made %d requests, want a rejected filter and a full scan", *requests)
+	}
+
+	_, err = client.Accounts.FindByCustomerID(context.Background(), "c9")
+	if _, ok := err.(*AccountNotFoundError); !ok {
+		t.Errorf("FindByCustomerID returned error %v, want *AccountNotFoundError", err)
+	}
+}
+
+func TestUnit_AccountsService_List_Filter(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		testFormValues(t, r, values{
+			"page[size]":           "10",
+			"filter[bank_id_code]": "GBDSC",
+			"filter[country]":      "GB",
+		})
+		w.Write([]byte(`{"data": []}`))
+	})
+
+	_, _, err := client.Accounts.ListWithOptions(context.Background(), &AccountListOptions{
+		ListOptions: ListOptions{PageSize: 10},
+		Filter:      &AccountFilter{BankIdCode: BankIdCodeGBDSC, Country: CountryGB},
+	})
+	if err != nil {
+		t.Errorf("List returned error: %v", err)
+	}
+}
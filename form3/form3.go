@@ -28,12 +28,33 @@ const (
 type Client struct {
 	client *http.Client // HTTP client used to communicate with the API.
 
+	middleware  []Middleware        // Middleware wrapping client, outermost first.
+	doer        Doer                // client wrapped in authorizer and middleware.
+	credentials CredentialsProvider // Credentials to authenticate requests with, if any.
+	tokens      *tokenSource        // Access tokens for the client credentials.
+
+	// Organisation that listings are restricted to, for views returned by
+	// ForOrganisation.
+	organisationScope string
+
 	// Base URL for API requests.
 	BaseURL *url.URL
 
 	// User agent used when communicating with the Form3 API.
 	UserAgent string
 
+	// Organisation ID assigned to new resources that do not specify one.
+	OrganisationID string
+
+	// Generates the ID of new resources that do not specify one. Defaults
+	// to NewUUID.
+	IDGenerator IDGenerator
+
+	// Whether deprecated account attributes are replaced by their
+	// successors before accounts are created or updated. See
+	// NormaliseAccountAttributes.
+	NormaliseDeprecatedAttributes bool
+
 	common service
 
 	Accounts *AccountsService
@@ -51,7 +72,7 @@ func NewClient(httpClient *http.Client) *Client {
 	}
 	baseURL, _ := url.Parse(defaultBaseURL)
 
-	c := &Client{client: httpClient, BaseURL: baseURL, UserAgent: userAgent}
+	c := &Client{client: httpClient, doer: authorizer(httpClient), BaseURL: baseURL, UserAgent: userAgent, IDGenerator: NewUUID}
 	c.common.client = c
 	c.Accounts = (*AccountsService)(&c.common)
 	return c
@@ -134,6 +155,10 @@ func addOptions(s string, opts interface{}) (string, error) {
 // returned from Form3
 type Response struct {
 	*http.Response
+
+	// Warnings about changes made to the request before it was sent, such
+	// as normalisation of deprecated attributes.
+	Warnings []string
 }
 
 // Response content when status code is 400 Bad Request
@@ -190,15 +215,23 @@ func CheckResponse(r *http.Response) error {
 // interface, the raw response body will be written to v, without attempting to
 // first decode it.
 //
+// The request is authenticated with the client's credentials, obtained once
+// per call. Each attempt to send it, after any middleware, is authenticated
+// afresh with those credentials.
+//
 // The provided ctx must be non-nil, if it is nil an error is returned. If it is canceled or times out,
 // ctx.Err() will be returned.
 func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Response, error) {
 	if ctx == nil {
 		return nil, errors.New("context must be non-nil")
 	}
+	ctx, err := c.withCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
 	req = req.WithContext(ctx)
 
-	resp, err := c.client.Do(req)
+	resp, err := c.doer.Do(req)
 	if err != nil {
 		// If we got an error, and the context has been canceled,
 		// the context's error is probably more useful.
@@ -218,7 +251,11 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Res
 	err = CheckResponse(resp)
 
 	if v != nil {
-		if w, ok := v.(io.Writer); ok {
+		if d, ok := v.(bodyDecoder); ok {
+			if err == nil {
+				err = d.decodeBody(resp.Body)
+			}
+		} else if w, ok := v.(io.Writer); ok {
 			io.Copy(w, resp.Body)
 		} else {
 			decErr := json.NewDecoder(resp.Body).Decode(v)
@@ -234,6 +271,15 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Res
 	return response, err
 }
 
+// newID returns an identifier for a new resource using the IDGenerator of
+// the Client, falling back to NewUUID if none is set.
+func (c *Client) newID() (string, error) {
+	if c.IDGenerator == nil {
+		return NewUUID()
+	}
+	return c.IDGenerator()
+}
+
 // Bool is a helper routine that allocates a new bool value
 // to store v and returns a pointer to it.
 func Bool(v bool) *bool { return &v }
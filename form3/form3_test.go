@@ -18,7 +18,7 @@ const (
 	baseURLPath = "/v1"
 )
 
-func setupClientWithStubbedApi() (client *Client, mux *http.ServeMux, serverURL string, teardown func()) {
+func setupClientWithStubbedApi(opts ...Option) (client *Client, mux *http.ServeMux, serverURL string, teardown func()) {
 	// mux is the HTTP request multiplexer used with the test server.
 	mux = http.NewServeMux()
 
@@ -37,9 +37,11 @@ func setupClientWithStubbedApi() (client *Client, mux *http.ServeMux, serverURL
 
 	// client is the Form3 client being tested and is
 	// configured to use test server.
-	client = NewClient(nil)
-	url, _ := url.Parse(server.URL + baseURLPath + "/")
-	client.BaseURL = url
+	opts = append([]Option{WithBaseURL(server.URL + baseURLPath + "/")}, opts...)
+	client, err := NewClientWithOptions(opts...)
+	if err != nil {
+		panic(err)
+	}
 
 	return client, mux, server.URL, server.Close
 }
@@ -48,9 +50,10 @@ func setupClientWithFakedApi() (client *Client, serverURL string) {
 	// client is the Form3 client being tested and is
 	// configured to use docker service API.
 	serverURL = "http://accountapi:8080"
-	client = NewClient(nil)
-	url, _ := url.Parse(serverURL + baseURLPath + "/")
-	client.BaseURL = url
+	client, err := NewClientWithOptions(WithBaseURL(serverURL + baseURLPath + "/"))
+	if err != nil {
+		panic(err)
+	}
 
 	return client, serverURL
 }
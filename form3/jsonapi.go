@@ -0,0 +1,297 @@
+package form3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// A Document is a JSON:API document whose primary data is a single resource
+// of type T. Resources in Included that T refers to through its
+// relationships are resolved into T's relation fields when the document is
+// decoded (see Relationships).
+//
+// JSON:API docs: https://jsonapi.org/format/#document-structure
+type Document[T any] struct {
+	Data     *T       `json:"data"`
+	Included Included `json:"included,omitempty"`
+	Links    *Links   `json:"links,omitempty"`
+	Meta     Meta     `json:"meta,omitempty"`
+}
+
+// A ListDocument is a JSON:API document whose primary data is a list of
+// resources of type T. Relations are resolved as for Document.
+type ListDocument[T any] struct {
+	Data     []*T     `json:"data"`
+	Included Included `json:"included,omitempty"`
+	Links    *Links   `json:"links,omitempty"`
+	Meta     Meta     `json:"meta,omitempty"`
+}
+
+// document and listDocument have the fields of Document and ListDocument
+// without their UnmarshalJSON methods.
+type document[T any] struct {
+	Data     *T       `json:"data"`
+	Included Included `json:"included,omitempty"`
+	Links    *Links   `json:"links,omitempty"`
+	Meta     Meta     `json:"meta,omitempty"`
+}
+
+type listDocument[T any] struct {
+	Data     []*T     `json:"data"`
+	Included Included `json:"included,omitempty"`
+	Links    *Links   `json:"links,omitempty"`
+	Meta     Meta     `json:"meta,omitempty"`
+}
+
+// UnmarshalJSON decodes d and resolves the relations of its data.
+func (d *Document[T]) UnmarshalJSON(data []byte) error {
+	var doc document[T]
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	*d = Document[T](doc)
+	if d.Data == nil {
+		return nil
+	}
+	return resolveRelations(reflect.ValueOf(d.Data), d.Included, nil)
+}
+
+// UnmarshalJSON decodes d and resolves the relations of its data.
+func (d *ListDocument[T]) UnmarshalJSON(data []byte) error {
+	var doc listDocument[T]
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	*d = ListDocument[T](doc)
+	for _, v := range d.Data {
+		if v == nil {
+			continue
+		}
+		if err := resolveRelations(reflect.ValueOf(v), d.Included, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Meta holds non-standard meta-information about a document, resource or
+// relationship.
+type Meta map[string]interface{}
+
+// A ResourceIdentifier identifies a single resource.
+type ResourceIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Meta Meta   `json:"meta,omitempty"`
+}
+
+// A Relationship refers from a resource to other resources. To-one
+// relationships have at most one identifier in Data, and are encoded as a
+// single resource identifier or null; to-many relationships are encoded as
+// a list.
+type Relationship struct {
+	Data   []*ResourceIdentifier
+	ToMany bool
+	Links  *Links
+	Meta   Meta
+}
+
+// relationship is the encoding of a Relationship.
+type relationship struct {
+	Data  json.RawMessage `json:"data,omitempty"`
+	Links *Links          `json:"links,omitempty"`
+	Meta  Meta            `json:"meta,omitempty"`
+}
+
+// MarshalJSON encodes r as a JSON:API relationship object.
+func (r *Relationship) MarshalJSON() ([]byte, error) {
+	var data interface{}
+	switch {
+	case r.ToMany:
+		ids := r.Data
+		if ids == nil {
+			ids = []*ResourceIdentifier{}
+		}
+		data = ids
+	case len(r.Data) > 1:
+		return nil, fmt.Errorf("to-one relationship has %d resource identifiers", len(r.Data))
+	case len(r.Data) == 1:
+		data = r.Data[0]
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&relationship{Data: raw, Links: r.Links, Meta: r.Meta})
+}
+
+// UnmarshalJSON decodes a JSON:API relationship object into r.
+func (r *Relationship) UnmarshalJSON(data []byte) error {
+	var rel relationship
+	if err := json.Unmarshal(data, &rel); err != nil {
+		return err
+	}
+	*r = Relationship{Links: rel.Links, Meta: rel.Meta}
+
+	raw := bytes.TrimSpace(rel.Data)
+	switch {
+	case len(raw) == 0 || string(raw) == "null":
+		return nil
+	case raw[0] == '[':
+		r.ToMany = true
+		return json.Unmarshal(raw, &r.Data)
+	}
+	id := new(ResourceIdentifier)
+	if err := json.Unmarshal(raw, id); err != nil {
+		return err
+	}
+	r.Data = []*ResourceIdentifier{id}
+	return nil
+}
+
+// Relationships holds the relationships of a resource by name.
+//
+// A resource type declares its relationships in a field of this type, and
+// may declare relation fields, tagged with the name of a relationship, to
+// hold the related resources:
+//
+//	Relationships  Relationships `json:"relationships,omitempty"`
+//	MasterAccounts []*Account    `json:"-" jsonapi:"master_account"`
+//
+// When a Document or ListDocument is decoded, each relation field is set to
+// the related resources found in the document's included resources. Fields
+// of a pointer type hold a to-one relation and fields of a slice of pointers
+// a to-many relation. Related resources are resolved in turn, except those
+// that refer back to a resource already being resolved, which are decoded
+// but not followed further.
+type Relationships map[string]*Relationship
+
+// A Resource is an undecoded resource object, such as an included resource.
+type Resource struct {
+	Type string
+	ID   string
+	Raw  json.RawMessage
+}
+
+// UnmarshalJSON records the type and ID of the resource in data, keeping a
+// copy of data to decode later.
+func (r *Resource) UnmarshalJSON(data []byte) error {
+	var id ResourceIdentifier
+	if err := json.Unmarshal(data, &id); err != nil {
+		return err
+	}
+	r.Type, r.ID = id.Type, id.ID
+	r.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// MarshalJSON returns the resource as it was decoded.
+func (r *Resource) MarshalJSON() ([]byte, error) {
+	if r.Raw == nil {
+		return json.Marshal(&ResourceIdentifier{Type: r.Type, ID: r.ID})
+	}
+	return r.Raw, nil
+}
+
+// Decode decodes the resource into v.
+func (r *Resource) Decode(v interface{}) error {
+	return json.Unmarshal(r.Raw, v)
+}
+
+// Included holds the resources included in a compound document.
+type Included []*Resource
+
+// Find returns the included resource with the given type and ID, or nil if
+// there is none.
+func (inc Included) Find(typ, id string) *Resource {
+	for _, r := range inc {
+		if r.Type == typ && r.ID == id {
+			return r
+		}
+	}
+	return nil
+}
+
+var relationshipsType = reflect.TypeOf(Relationships(nil))
+
+// resolveRelations sets the relation fields of the resource v points to from
+// the resources in inc. Resources being resolved are recorded in path, so
+// that relationships that refer back to them are not followed again.
+func resolveRelations(v reflect.Value, inc Included, path map[string]bool) error {
+	if len(inc) == 0 || v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	s := v.Elem()
+	t := s.Type()
+
+	var rels Relationships
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type == relationshipsType {
+			rels = s.Field(i).Interface().(Relationships)
+			break
+		}
+	}
+	if rels == nil {
+		return nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("jsonapi"), ",")[0]
+		if name == "" {
+			continue
+		}
+		rel := rels[name]
+		if rel == nil {
+			continue
+		}
+		field := s.Field(i)
+
+		var elemType reflect.Type
+		switch {
+		case field.Kind() == reflect.Ptr:
+			elemType = field.Type().Elem()
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Ptr:
+			elemType = field.Type().Elem().Elem()
+		default:
+			return fmt.Errorf("relation field %s.%s must be a pointer or a slice of pointers", t.Name(), t.Field(i).Name)
+		}
+
+		var related []reflect.Value
+		for _, id := range rel.Data {
+			res := inc.Find(id.Type, id.ID)
+			if res == nil {
+				continue
+			}
+			r := reflect.New(elemType)
+			if err := res.Decode(r.Interface()); err != nil {
+				return fmt.Errorf("decoding included %s %s: %v", id.Type, id.ID, err)
+			}
+			key := id.Type + "/" + id.ID
+			if !path[key] {
+				p := map[string]bool{key: true}
+				for k := range path {
+					p[k] = true
+				}
+				if err := resolveRelations(r, inc, p); err != nil {
+					return err
+				}
+			}
+			related = append(related, r)
+		}
+
+		if field.Kind() == reflect.Ptr {
+			if len(related) > 0 {
+				field.Set(related[0])
+			}
+			continue
+		}
+		if len(related) > 0 {
+			slice := reflect.MakeSlice(field.Type(), 0, len(related))
+			field.Set(reflect.Append(slice, related...))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package form3
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestUnit_Document_Included(t *testing.T) {
+	data := `{
+		"data": {
+			"type": "accounts",
+			"id": "1",
+			"attributes": {"country": "GB"},
+			"relationships": {
+				"master_account": {"data": [{"type": "accounts", "id": "2"}, {"type": "accounts", "id": "9"}]}
+			}
+		},
+		"included": [
+			{
+				"type": "accounts",
+				"id": "2",
+				"attributes": {"country": "FR"},
+				"relationships": {"master_account": {"data": [{"type": "accounts", "id": "2"}]}}
+			}
+		],
+		"links": {"self": "/v1/organisation/accounts/1"},
+		"meta": {"count": 1}
+	}`
+
+	doc := new(Document[Account])
+	if err := json.Unmarshal([]byte(data), doc); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if got, want := *doc.Links.Self, "/v1/organisation/accounts/1"; got != want {
+		t.Errorf("Links.Self = %v, want %v", got, want)
+	}
+	if got, want := doc.Meta["count"], float64(1); got != want {
+		t.Errorf("Meta[count] = %v, want %v", got, want)
+	}
+
+	rel := doc.Data.Relationships["master_account"]
+	if rel == nil || !rel.ToMany || len(rel.Data) != 2 {
+		t.Fatalf("Relationship master_account = %+v, want 2 identifiers", rel)
+	}
+	if len(doc.Data.MasterAccounts) != 1 {
+		t.Fatalf("Resolved %d master accounts, want 1", len(doc.Data.MasterAccounts))
+	}
+	master := doc.Data.MasterAccounts[0]
+	if *master.ID != "2" || *master.Attributes.Country != CountryFR {
+		t.Errorf("Resolved master account %v, want account 2 in FR", *master.ID)
+	}
+	if len(master.MasterAccounts) != 1 || master.MasterAccounts[0].MasterAccounts != nil {
+		t.Errorf("Followed relationship of account 2 to itself more than once")
+	}
+}
+
+func TestUnit_ListDocument_Included(t *testing.T) {
+	data := `{
+		"data": [
+			{"type": "accounts", "id": "1", "relationships": {"master_account": {"data": [{"type": "accounts", "id": "3"}]}}},
+			{"type": "accounts", "id": "2"}
+		],
+		"included": [{"type": "accounts", "id": "3"}]
+	}`
+
+	doc := new(ListDocument[Account])
+	if err := json.Unmarshal([]byte(data), doc); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if len(doc.Data) != 2 {
+		t.Fatalf("Decoded %d accounts, want 2", len(doc.Data))
+	}
+	if len(doc.Data[0].MasterAccounts) != 1 || *doc.Data[0].MasterAccounts[0].ID != "3" {
+		t.Errorf("Account 1 master accounts = %v, want account 3", doc.Data[0].MasterAccounts)
+	}
+	if doc.Data[1].MasterAccounts != nil {
+		t.Errorf("Account 2 master accounts = %v, want none", doc.Data[1].MasterAccounts)
+	}
+}
+
+type testOrganisation struct {
+	ID            string            `json:"id"`
+	Relationships Relationships     `json:"relationships"`
+	Parent        *testOrganisation `json:"-" jsonapi:"parent"`
+}
+
+func TestUnit_Document_ToOne(t *testing.T) {
+	data := `{
+		"data": {"type": "organisations", "id": "a", "relationships": {"parent": {"data": {"type": "organisations", "id": "b"}}}},
+		"included": [{"type": "organisations", "id": "b", "relationships": {"parent": {"data": null}}}]
+	}`
+
+	doc := new(Document[testOrganisation])
+	if err := json.Unmarshal([]byte(data), doc); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if rel := doc.Data.Relationships["parent"]; rel.ToMany || len(rel.Data) != 1 {
+		t.Errorf("Relationship parent = %+v, want one identifier", rel)
+	}
+	if doc.Data.Parent == nil || doc.Data.Parent.ID != "b" {
+		t.Fatalf("Resolved parent %+v, want organisation b", doc.Data.Parent)
+	}
+	if doc.Data.Parent.Parent != nil {
+		t.Errorf("Resolved parent of b %+v, want none", doc.Data.Parent.Parent)
+	}
+}
+
+func TestUnit_Relationship_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		rel  *Relationship
+		want string
+	}{
+		{&Relationship{}, `{"data":null}`},
+		{&Relationship{Data: []*ResourceIdentifier{{Type: "accounts", ID: "1"}}}, `{"data":{"type":"accounts","id":"1"}}`},
+		{&Relationship{ToMany: true}, `{"data":[]}`},
+		{&Relationship{ToMany: true, Data: []*ResourceIdentifier{{Type: "accounts", ID: "1"}}}, `{"data":[{"type":"accounts","id":"1"}]}`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.rel)
+		if err != nil {
+			t.Fatalf("Marshal returned error: %v", err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("Marshal = %s, want %s", data, tt.want)
+		}
+
+		got := new(Relationship)
+		if err := json.Unmarshal(data, got); err != nil {
+			t.Fatalf("Unmarshal returned error: %v", err)
+		}
+		if got.ToMany != tt.rel.ToMany || len(got.Data) != len(tt.rel.Data) {
+			t.Errorf("Round trip of %s = %+v", data, got)
+		}
+	}
+
+	if _, err := json.Marshal(&Relationship{Data: make([]*ResourceIdentifier, 2)}); err == nil {
+		t.Errorf("Expected error marshalling a to-one relationship with two identifiers")
+	}
+}
+
+func TestUnit_Document_MarshalJSON(t *testing.T) {
+	doc := &AccountCreation{Data: &Account{ID: String("1"), Attributes: &AccountAttributes{Country: CountryGB.Ptr()}}}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	var got map[string]interface{}
+	json.Unmarshal(data, &got)
+	if keys := reflect.ValueOf(got).MapKeys(); len(keys) != 1 || keys[0].String() != "data" {
+		t.Errorf("Marshal = %s, want only data", data)
+	}
+}
@@ -0,0 +1,115 @@
+package form3
+
+import (
+	"context"
+)
+
+// ListAsyncOptions specifies the optional parameters to
+// AccountsService.ListAsync.
+type ListAsyncOptions struct {
+	// Accounts to list and page size, as for ListAll.
+	AccountListOptions
+
+	// Capacity of the accounts channel. Defaults to the page size.
+	BufferSize int
+}
+
+// ListAsync lists the accounts matching opts in the background, sending them
+// on the returned accounts channel in order. The next page is requested as
+// soon as the current one has been received, so that it is ready by the
+// time the consumer has worked through the current page.
+//
+// The accounts channel is closed once every account has been sent, or
+// listing stops because of an error or ctx being done. The error channel
+// then receives the error, if any, and is closed:
+//
+//	accounts, errc := client.Accounts.ListAsync(ctx, nil)
+//	for account := range accounts {
+//		...
+//	}
+//	if err := <-errc; err != nil {
+//		...
+//	}
+//
+// To stop early, cancel ctx; the background goroutines exit without the
+// accounts channel needing to be drained.
+func (s *AccountsService) ListAsync(ctx context.Context, opts *ListAsyncOptions) (<-chan *Account, <-chan error) {
+	o := ListAsyncOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.PageSize <= 0 {
+		o.PageSize = defaultPageSize
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = o.PageSize
+	}
+
+	accounts := make(chan *Account, o.BufferSize)
+	errc := make(chan error, 1)
+	pages := make(chan []*Account)
+
+	// fetchErr is written by the fetcher before it closes pages, and read by
+	// the sender once pages is closed.
+	var fetchErr error
+
+	go func() {
+		defer close(pages)
+		fetchErr = s.fetchPages(ctx, &o.AccountListOptions, pages)
+	}()
+
+	go func() {
+		defer close(errc)
+		err := sendAccounts(ctx, pages, accounts)
+		close(accounts)
+
+		// Wait for the fetcher to stop, which it does promptly once ctx is
+		// done, so that no goroutine outlives the channels.
+		for range pages {
+		}
+		if err == nil {
+			err = fetchErr
+		}
+		if err != nil {
+			errc <- err
+		}
+	}()
+
+	return accounts, errc
+}
+
+// fetchPages sends each page of accounts matching opts on pages, stopping
+// after the last page or at the first error.
+func (s *AccountsService) fetchPages(ctx context.Context, opts *AccountListOptions, pages chan<- []*Account) error {
+	o := *opts
+	for {
+		page, _, err := s.ListWithOptions(ctx, &o)
+		if err != nil {
+			return err
+		}
+		select {
+		case pages <- page.Data:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if isLastPage(page, o.PageSize) {
+			return nil
+		}
+		o.PageNumber++
+	}
+}
+
+// sendAccounts sends the accounts of each page received on pages until pages
+// is closed or ctx is done.
+func sendAccounts(ctx context.Context, pages <-chan []*Account, accounts chan<- *Account) error {
+	for page := range pages {
+		for _, account := range page {
+			select {
+			case accounts <- account:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package form3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// collectAsync ranges over the accounts from ListAsync, returning their IDs
+// and the error received.
+func collectAsync(accounts <-chan *Account, errc <-chan error) ([]string, error) {
+	var ids []string
+	for a := range accounts {
+		ids = append(ids, *a.ID)
+	}
+	return ids, <-errc
+}
+
+// waitForGoroutines waits for the number of goroutines to fall to n,
+// failing the test if it does not.
+func waitForGoroutines(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > n {
+		if time.Now().After(deadline) {
+			t.Errorf("%d goroutines running, want at most %d", runtime.NumGoroutine(), n)
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestUnit_AccountsService_ListAsync(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	serveAccounts(t, mux, findTestAccounts, true)
+
+	accounts, errc := client.Accounts.ListAsync(context.Background(), &ListAsyncOptions{AccountListOptions: AccountListOptions{ListOptions: ListOptions{PageSize: 2}}})
+	ids, err := collectAsync(accounts, errc)
+	if err != nil {
+		t.Fatalf("ListAsync returned error: %v", err)
+	}
+	if want := []string{"1", "2", "3", "4", "5"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ListAsync sent %v, want %v", ids, want)
+	}
+}
+
+func TestUnit_AccountsService_ListAsync_CappedPageSize(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	serveCappedAccounts(mux, findTestAccounts)
+
+	accounts, errc := client.Accounts.ListAsync(context.Background(), &ListAsyncOptions{AccountListOptions: AccountListOptions{ListOptions: ListOptions{PageSize: 3}}})
+	ids, err := collectAsync(accounts, errc)
+	if err != nil {
+		t.Fatalf("ListAsync returned error: %v", err)
+	}
+	if want := []string{"1", "2", "3", "4", "5"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ListAsync sent %v from pages shorter than asked for, want %v", ids, want)
+	}
+}
+
+func TestUnit_AccountsService_ListAsync_Prefetch(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	var requested int32 = -1
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		number, _ := strconv.Atoi(r.URL.Query().Get("page[number]"))
+		atomic.StoreInt32(&requested, int32(number))
+		fmt.Fprintf(w, `{"data": [{"id": "%d-a"}, {"id": "%d-b"}]}`, number, number)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts := &ListAsyncOptions{AccountListOptions: AccountListOptions{ListOptions: ListOptions{PageSize: 2}}, BufferSize: 1}
+	accounts, _ := client.Accounts.ListAsync(ctx, opts)
+
+	if a := <-accounts; *a.ID != "0-a" {
+		t.Fatalf("ListAsync sent %v first, want 0-a", *a.ID)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(&requested) < 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Next page not requested while the first page is being consumed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := atomic.LoadInt32(&requested); got > 2 {
+		t.Errorf("Requested page %d ahead of the consumer, want at most page 2", got)
+	}
+}
+
+func TestUnit_AccountsService_ListAsync_Cancel(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [{"id": "a"}, {"id": "b"}]}`)
+	})
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	opts := &ListAsyncOptions{AccountListOptions: AccountListOptions{ListOptions: ListOptions{PageSize: 2}}, BufferSize: 1}
+	accounts, errc := client.Accounts.ListAsync(ctx, opts)
+	<-accounts
+	cancel()
+
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("ListAsync returned error %v, want %v", err, context.Canceled)
+	}
+	for range accounts {
+	}
+	client.client.CloseIdleConnections()
+	waitForGoroutines(t, before)
+}
+
+func TestUnit_AccountsService_ListAsync_Error(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page[number]") == "1" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"id": "a"}, {"id": "b"}]}`)
+	})
+
+	opts := &ListAsyncOptions{AccountListOptions: AccountListOptions{ListOptions: ListOptions{PageSize: 2}}}
+	ids, err := collectAsync(client.Accounts.ListAsync(context.Background(), opts))
+	if want := []string{"a", "b"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ListAsync sent %v before failing, want %v", ids, want)
+	}
+	if _, ok := err.(*ErrorResponse); !ok {
+		t.Errorf("ListAsync returned error %v, want *ErrorResponse", err)
+	}
+}
@@ -0,0 +1,208 @@
+package form3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// requestIDHeader is the header Form3 uses to identify a request in its logs.
+const requestIDHeader = "X-Request-Id"
+
+// redacted replaces the value of sensitive fields in logged bodies.
+const redacted = "[REDACTED]"
+
+// sensitiveFields are the JSON keys whose values are redacted from logged
+// bodies. They hold account numbers, IBANs and account holder names.
+var sensitiveFields = map[string]bool{
+	"account_number":                 true,
+	"iban":                           true,
+	"name":                           true,
+	"alternative_names":              true,
+	"title":                          true,
+	"first_name":                     true,
+	"bank_account_name":              true,
+	"alternative_bank_account_names": true,
+	"secondary_identification":       true,
+}
+
+// A RequestLog describes a single request made to the Form3 API and its
+// outcome.
+type RequestLog struct {
+	Method    string
+	URL       string
+	Status    int           // HTTP status code, zero if no response was received
+	Latency   time.Duration // time taken to receive the response headers
+	RequestID string        // value of the X-Request-Id response header, if any
+	Err       error         // transport error, if any
+
+	// Request and response bodies with sensitive fields redacted. Only set
+	// when bodies are logged.
+	RequestBody  []byte
+	ResponseBody []byte
+}
+
+// A Logger records requests made by a Client. See LoggingMiddleware.
+type Logger interface {
+	LogRequest(ctx context.Context, entry *RequestLog)
+}
+
+// The LoggerFunc type is an adapter to allow the use of ordinary functions as
+// a Logger.
+type LoggerFunc func(ctx context.Context, entry *RequestLog)
+
+// LogRequest calls f(ctx, entry).
+func (f LoggerFunc) LogRequest(ctx context.Context, entry *RequestLog) {
+	f(ctx, entry)
+}
+
+// NewStdLogger returns a Logger that writes one line per request to l. If l
+// is nil the standard logger of the log package is used.
+func NewStdLogger(l *log.Logger) Logger {
+	if l == nil {
+		l = log.New(log.Writer(), log.Prefix(), log.Flags())
+	}
+	return LoggerFunc(func(ctx context.Context, e *RequestLog) {
+		if e.Err != nil {
+			l.Printf("form3: %s %s failed after %v: %v", e.Method, e.URL, e.Latency, e.Err)
+			return
+		}
+		l.Printf("form3: %s %s %d %v request_id=%s", e.Method, e.URL, e.Status, e.Latency, e.RequestID)
+		if e.RequestBody != nil {
+			l.Printf("form3: request body: %s", e.RequestBody)
+		}
+		if e.ResponseBody != nil {
+			l.Printf("form3: response body: %s", e.ResponseBody)
+		}
+	})
+}
+
+// LoggingMiddleware returns a Middleware that reports every request to
+// logger. Filters on account numbers, IBANs and names are redacted from the
+// URL logged. If logBodies is true the request and response bodies are
+// included, with account numbers, IBANs and names redacted.
+func LoggingMiddleware(logger Logger, logBodies bool) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			entry := &RequestLog{Method: req.Method, URL: RedactURL(req.URL)}
+			if logBodies && req.GetBody != nil {
+				if body, err := req.GetBody(); err == nil {
+					data, _ := ioutil.ReadAll(body)
+					body.Close()
+					entry.RequestBody = RedactJSON(data)
+				}
+			}
+
+			start := time.Now()
+			resp, err := next.Do(req)
+			entry.Latency = time.Since(start)
+			entry.Err = err
+
+			if resp != nil {
+				entry.Status = resp.StatusCode
+				entry.RequestID = resp.Header.Get(requestIDHeader)
+				if logBodies {
+					data, _ := ioutil.ReadAll(resp.Body)
+					resp.Body.Close()
+					resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+					entry.ResponseBody = RedactJSON(data)
+				}
+			}
+
+			logger.LogRequest(req.Context(), entry)
+			return resp, err
+		})
+	}
+}
+
+// RedactURL returns u as a string with the values of query parameters that
+// filter on account numbers, IBANs and names, such as filter[iban],
+// replaced.
+func RedactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	q, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		r := *u
+		r.RawQuery = redacted
+		return r.String()
+	}
+	changed := false
+	for key := range q {
+		if strings.HasPrefix(key, "filter[") && strings.HasSuffix(key, "]") &&
+			sensitiveFields[strings.TrimSuffix(strings.TrimPrefix(key, "filter["), "]")] {
+			q.Set(key, redacted)
+			changed = true
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+	r := *u
+	r.RawQuery = q.Encode()
+	return r.String()
+}
+
+// RedactJSON returns a copy of the JSON document data with the values of
+// fields holding account numbers, IBANs and names replaced, at any depth.
+// Empty input is returned as is. Input that is not valid JSON is replaced
+// entirely, as it cannot be safely inspected.
+func RedactJSON(data []byte) []byte {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return data
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return []byte(redacted)
+	}
+
+	out, err := json.Marshal(redactValue(doc))
+	if err != nil {
+		return []byte(redacted)
+	}
+	return out
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if sensitiveFields[k] {
+				v[k] = redactSensitive(field)
+			} else {
+				v[k] = redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = redactValue(elem)
+		}
+	}
+	return v
+}
+
+// redactSensitive replaces a sensitive value, preserving the shape of lists
+// so the number of name lines remains visible.
+func redactSensitive(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		for i := range v {
+			v[i] = redacted
+		}
+		return v
+	default:
+		return redacted
+	}
+}
@@ -0,0 +1,171 @@
+package form3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnit_RedactJSON_AccountAttributes(t *testing.T) {
+	attributes := &AccountAttributes{
+		Country:          CountryGB.Ptr(),
+		BankId:           String("400300"),
+		AccountNumber:    String("41426819"),
+		IBAN:             String("GB11NWBK40030041426819"),
+		Name:             []string{"Samantha Holder", "Sam Holder"},
+		AlternativeNames: []string{"Sam H"},
+		FirstName:        String("Samantha"),
+		BankAccountName:  String("S Holder"),
+	}
+	data, _ := json.Marshal(&AccountCreation{Data: &Account{ID: String("1d50df61-db36-483c-9975-41141d691be1"), Attributes: attributes}})
+
+	redactedData := RedactJSON(data)
+
+	for _, secret := range []string{"41426819", "GB11NWBK40030041426819", "Samantha", "Sam H", "S Holder"} {
+		if bytes.Contains(redactedData, []byte(secret)) {
+			t.Errorf("RedactJSON output %s contains %q", redactedData, secret)
+		}
+	}
+
+	got := new(AccountCreation)
+	if err := json.Unmarshal(redactedData, got); err != nil {
+		t.Fatalf("RedactJSON output is not valid JSON: %v", err)
+	}
+	want := &AccountCreation{
+		Data: &Account{
+			ID: String("1d50df61-db36-483c-9975-41141d691be1"),
+			Attributes: &AccountAttributes{
+				Country:          CountryGB.Ptr(),
+				BankId:           String("400300"),
+				AccountNumber:    String(redacted),
+				IBAN:             String(redacted),
+				Name:             []string{redacted, redacted},
+				AlternativeNames: []string{redacted},
+				FirstName:        String(redacted),
+				BankAccountName:  String(redacted),
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RedactJSON = %s, want attributes %+v", redactedData, want.Data.Attributes)
+	}
+}
+
+func TestUnit_RedactJSON_Invalid(t *testing.T) {
+	if got, want := string(RedactJSON([]byte(`{"iban": "GB11`))), redacted; got != want {
+		t.Errorf("RedactJSON = %v, want %v", got, want)
+	}
+	if got := RedactJSON(nil); got != nil {
+		t.Errorf("RedactJSON(nil) = %s, want nil", got)
+	}
+}
+
+func TestUnit_LoggingMiddleware(t *testing.T) {
+	var entries []*RequestLog
+	logger := LoggerFunc(func(ctx context.Context, entry *RequestLog) {
+		entries = append(entries, entry)
+	})
+	client, mux, _, teardown := setupClientWithStubbedApi(WithMiddleware(LoggingMiddleware(logger, true)))
+	defer teardown()
+
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(requestIDHeader, "c7fb7ff6-2a6c-4d51-a24b-b1c1a5c4e2d6")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"data": {"attributes": {"iban": "GB11NWBK40030041426819"}}}`)
+	})
+
+	account, _, err := client.Accounts.Create(context.Background(), &Account{
+		Attributes: &AccountAttributes{AccountNumber: String("41426819")},
+	})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if got, want := *account.Attributes.IBAN, "GB11NWBK40030041426819"; got != want {
+		t.Errorf("Create returned IBAN %v, want %v", got, want)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("Logger called %d times, want 1", len(entries))
+	}
+	entry := entries[0]
+	if got, want := entry.Method, "POST"; got != want {
+		t.Errorf("RequestLog.Method = %v, want %v", got, want)
+	}
+	if !strings.HasSuffix(entry.URL, "/organisation/accounts") {
+		t.Errorf("RequestLog.URL = %v, want accounts URL", entry.URL)
+	}
+	if got, want := entry.Status, http.StatusCreated; got != want {
+		t.Errorf("RequestLog.Status = %v, want %v", got, want)
+	}
+	if got, want := entry.RequestID, "c7fb7ff6-2a6c-4d51-a24b-b1c1a5c4e2d6"; got != want {
+		t.Errorf("RequestLog.RequestID = %v, want %v", got, want)
+	}
+	if entry.Latency <= 0 {
+		t.Errorf("RequestLog.Latency = %v, want positive", entry.Latency)
+	}
+	if bytes.Contains(entry.RequestBody, []byte("41426819")) || !bytes.Contains(entry.RequestBody, []byte(redacted)) {
+		t.Errorf("RequestLog.RequestBody = %s, want account number redacted", entry.RequestBody)
+	}
+	if bytes.Contains(entry.ResponseBody, []byte("GB11NWBK40030041426819")) || !bytes.Contains(entry.ResponseBody, []byte(redacted)) {
+		t.Errorf("RequestLog.ResponseBody = %s, want IBAN redacted", entry.ResponseBody)
+	}
+}
+
+func TestUnit_LoggingMiddleware_WithoutBodies(t *testing.T) {
+	buf := new(bytes.Buffer)
+	client, mux, _, teardown := setupClientWithStubbedApi(WithMiddleware(LoggingMiddleware(NewStdLogger(log.New(buf, "", 0)), false)))
+	defer teardown()
+
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error_message": "record 1 does not exist"}`)
+	})
+
+	_, _, err := client.Accounts.Fetch(context.Background(), "1")
+	if err == nil || !strings.Contains(err.Error(), "record 1 does not exist") {
+		t.Errorf("Fetch returned error %v, want API error", err)
+	}
+
+	if got := buf.String(); !strings.Contains(got, "GET") || !strings.Contains(got, " 404 ") || strings.Contains(got, "body") {
+		t.Errorf("Logger wrote %q, want single status line", got)
+	}
+}
+
+func TestUnit_RedactURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.form3.tech/v1/organisation/accounts/1", "https://api.form3.tech/v1/organisation/accounts/1"},
+		{"https://api.form3.tech/v1/organisation/accounts?filter%5Bcustomer_id%5D=c1&page%5Bsize%5D=100", "https://api.form3.tech/v1/organisation/accounts?filter%5Bcustomer_id%5D=c1&page%5Bsize%5D=100"},
+		{"https://api.form3.tech/v1/organisation/accounts?filter%5Biban%5D=GB11NWBK40030041426819", "https://api.form3.tech/v1/organisation/accounts?filter%5Biban%5D=%5BREDACTED%5D"},
+		{"https://api.form3.tech/v1/organisation/accounts?filter%5Baccount_number%5D=41426819&filter%5Bbank_id%5D=400300", "https://api.form3.tech/v1/organisation/accounts?filter%5Baccount_number%5D=%5BREDACTED%5D&filter%5Bbank_id%5D=400300"},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if got := RedactURL(u); got != tt.want {
+			t.Errorf("RedactURL(%s) = %s, want %s", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestUnit_LoggingMiddleware_RedactsFilters(t *testing.T) {
+	buf := new(bytes.Buffer)
+	client, mux, _, teardown := setupClientWithStubbedApi(WithMiddleware(LoggingMiddleware(NewStdLogger(log.New(buf, "", 0)), false)))
+	defer teardown()
+	serveAccounts(t, mux, findTestAccounts, true)
+
+	if _, err := client.Accounts.FindByIBAN(context.Background(), "GB11NWBK40030041426819"); err != nil {
+		t.Fatalf("FindByIBAN returned error: %v", err)
+	}
+	if got := buf.String(); strings.Contains(got, "GB11NWBK40030041426819") || !strings.Contains(got, "REDACTED") {
+		t.Errorf("Logger wrote %q, want the IBAN filter redacted", got)
+	}
+}
@@ -0,0 +1,40 @@
+package form3
+
+import "net/http"
+
+// A Doer sends an HTTP request and returns the HTTP response. *http.Client
+// implements Doer.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// The DoerFunc type is an adapter to allow the use of ordinary functions as
+// a Doer.
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f(req).
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// A Middleware wraps the Doer used to send requests, to add behaviour such as
+// retries, logging or metrics around every request made by a Client.
+//
+// Middleware is applied in the order it is given to the Client: the first
+// middleware is the outermost, seeing each request first and each response
+// last, and the last middleware wraps the underlying *http.Client. Requests
+// are authenticated inside the last middleware, so middleware does not see
+// the Authorization header, and each attempt is authenticated afresh. A
+// middleware sees each attempt made by the middleware outside it, so for
+// example logging added after retries records every attempt, while logging
+// added before retries records one entry per call.
+type Middleware func(next Doer) Doer
+
+// chain wraps doer in middleware, so that the first middleware is the
+// outermost.
+func chain(doer Doer, middleware []Middleware) Doer {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		doer = middleware[i](doer)
+	}
+	return doer
+}
@@ -0,0 +1,64 @@
+package form3
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func recordingMiddleware(name string, calls *[]string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			*calls = append(*calls, name+" before")
+			resp, err := next.Do(req)
+			*calls = append(*calls, name+" after")
+			return resp, err
+		})
+	}
+}
+
+func TestUnit_Middleware_Order(t *testing.T) {
+	var calls []string
+	client, mux, _, teardown := setupClientWithStubbedApi(
+		WithMiddleware(recordingMiddleware("first", &calls), recordingMiddleware("second", &calls)),
+		WithMiddleware(recordingMiddleware("third", &calls)),
+	)
+	defer teardown()
+
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "server")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req, _ := client.NewRequest("GET", "organisation/accounts", nil)
+	if _, err := client.Do(context.Background(), req, nil); err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+
+	want := []string{"first before", "second before", "third before", "server", "third after", "second after", "first after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("Calls = %v, want %v", calls, want)
+	}
+}
+
+func TestUnit_Middleware_SeesOperation(t *testing.T) {
+	var operation string
+	client, mux, _, teardown := setupClientWithStubbedApi(WithMiddleware(func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			operation, _ = OperationFromContext(req.Context())
+			return next.Do(req)
+		})
+	}))
+	defer teardown()
+
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	client.Accounts.Delete(context.Background(), "1", 0)
+
+	if got, want := operation, "accounts.delete"; got != want {
+		t.Errorf("Operation = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,361 @@
+package form3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// A Currency is an ISO 4217 currency code, e.g. "GBP".
+type Currency string
+
+// Commonly used currencies. Any currency in ISO 4217 is valid.
+const (
+	CurrencyAUD Currency = "AUD"
+	CurrencyCAD Currency = "CAD"
+	CurrencyCHF Currency = "CHF"
+	CurrencyEUR Currency = "EUR"
+	CurrencyGBP Currency = "GBP"
+	CurrencyHKD Currency = "HKD"
+	CurrencyPLN Currency = "PLN"
+	CurrencyUSD Currency = "USD"
+)
+
+// MinorUnits returns the number of decimal places used by the currency, e.g. 2
+// for GBP and 0 for JPY, and whether the currency is known.
+func (c Currency) MinorUnits() (int, bool) {
+	units, ok := currencyMinorUnits[c]
+	return units, ok
+}
+
+// IsValid reports whether c is an active ISO 4217 currency.
+func (c Currency) IsValid() bool {
+	_, ok := c.MinorUnits()
+	return ok
+}
+
+// Ptr returns a pointer to a copy of c.
+func (c Currency) Ptr() *Currency { return &c }
+
+// currencyMinorUnits holds the minor units of active ISO 4217 currencies.
+var currencyMinorUnits = map[Currency]int{
+	// Currencies without minor units.
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0,
+	"XPF": 0,
+
+	// Currencies with three decimal places.
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+
+	// Currencies with four decimal places.
+	"CLF": 4, "UYW": 4,
+
+	// Currencies with two decimal places.
+	"AED": 2, "AFN": 2, "ALL": 2, "AMD": 2, "ANG": 2, "AOA": 2, "ARS": 2, "AUD": 2,
+	"AWG": 2, "AZN": 2, "BAM": 2, "BBD": 2, "BDT": 2, "BGN": 2, "BMD": 2, "BND": 2,
+	"BOB": 2, "BRL": 2, "BSD": 2, "BTN": 2, "BWP": 2, "BYN": 2, "BZD": 2, "CAD": 2,
+	"CDF": 2, "CHF": 2, "CNY": 2, "COP": 2, "CRC": 2, "CUP": 2, "CVE": 2, "CZK": 2,
+	"DKK": 2, "DOP": 2, "DZD": 2, "EGP": 2, "ERN": 2, "ETB": 2, "EUR": 2, "FJD": 2,
+	"FKP": 2, "GBP": 2, "GEL": 2, "GHS": 2, "GIP": 2, "GMD": 2, "GTQ": 2, "GYD": 2,
+	"HKD": 2, "HNL": 2, "HTG": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2, "IRR": 2,
+	"JMD": 2, "KES": 2, "KGS": 2, "KHR": 2, "KPW": 2, "KYD": 2, "KZT": 2, "LAK": 2,
+	"LBP": 2, "LKR": 2, "LRD": 2, "LSL": 2, "MAD": 2, "MDL": 2, "MGA": 2, "MKD": 2,
+	"MMK": 2, "MNT": 2, "MOP": 2, "MRU": 2, "MUR": 2, "MVR": 2, "MWK": 2, "MXN": 2,
+	"MYR": 2, "MZN": 2, "NAD": 2, "NGN": 2, "NIO": 2, "NOK": 2, "NPR": 2, "NZD": 2,
+	"PAB": 2, "PEN": 2, "PGK": 2, "PHP": 2, "PKR": 2, "PLN": 2, "QAR": 2, "RON": 2,
+	"RSD": 2, "RUB": 2, "SAR": 2, "SBD": 2, "SCR": 2, "SDG": 2, "SEK": 2, "SGD": 2,
+	"SHP": 2, "SLE": 2, "SOS": 2, "SRD": 2, "SSP": 2, "STN": 2, "SVC": 2, "SYP": 2,
+	"SZL": 2, "THB": 2, "TJS": 2, "TMT": 2, "TOP": 2, "TRY": 2, "TTD": 2, "TWD": 2,
+	"TZS": 2, "UAH": 2, "USD": 2, "UYU": 2, "UZS": 2, "VES": 2, "WST": 2, "XCD": 2,
+	"YER": 2, "ZAR": 2, "ZMW": 2, "ZWL": 2,
+}
+
+// An Amount is an exact decimal number, such as a payment amount. It is
+// encoded in JSON as a decimal string, e.g. "1234.50", the way the Form3 API
+// expects, and keeps the number of decimal places it was given so that it
+// round-trips unchanged. The zero value is 0.
+type Amount struct {
+	unscaled *big.Int // value is unscaled / 10^scale; nil means zero
+	scale    int      // number of decimal places
+}
+
+// ParseAmount parses a decimal string such as "1234.50" or "-3". Exponents,
+// thousands separators and leading or trailing dots are not accepted.
+func ParseAmount(s string) (Amount, error) {
+	digits := strings.TrimPrefix(s, "-")
+	intPart, fracPart := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		intPart, fracPart = digits[:i], digits[i+1:]
+		if fracPart == "" {
+			return Amount{}, fmt.Errorf("invalid amount %q", s)
+		}
+	}
+	if intPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+
+	unscaled, _ := new(big.Int).SetString(intPart+fracPart, 10)
+	if len(digits) != len(s) {
+		unscaled.Neg(unscaled)
+	}
+	return Amount{unscaled: unscaled, scale: len(fracPart)}, nil
+}
+
+// MustParseAmount is like ParseAmount but panics if s cannot be parsed. It is
+// intended for constants in code and tests.
+func MustParseAmount(s string) Amount {
+	a, err := ParseAmount(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// NewAmountFromMinorUnits returns the amount of minor units, e.g. pence, with
+// the given number of decimal places, e.g. NewAmountFromMinorUnits(1050, 2) is
+// 10.50.
+func NewAmountFromMinorUnits(units int64, scale int) Amount {
+	return Amount{unscaled: big.NewInt(units), scale: scale}
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (a Amount) value() *big.Int {
+	if a.unscaled == nil {
+		return new(big.Int)
+	}
+	return a.unscaled
+}
+
+// Scale returns the number of decimal places of a.
+func (a Amount) Scale() int { return a.scale }
+
+// Sign returns -1, 0 or +1 as a is negative, zero or positive.
+func (a Amount) Sign() int { return a.value().Sign() }
+
+// IsZero reports whether a is zero.
+func (a Amount) IsZero() bool { return a.Sign() == 0 }
+
+// String returns a as a decimal string with Scale decimal places.
+func (a Amount) String() string {
+	digits := new(big.Int).Abs(a.value()).String()
+	if a.scale > 0 {
+		if len(digits) <= a.scale {
+			digits = strings.Repeat("0", a.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-a.scale] + "." + digits[len(digits)-a.scale:]
+	}
+	if a.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// rescale returns the unscaled value of a with scale decimal places, which
+// must not be less than the scale of a.
+func (a Amount) rescale(scale int) *big.Int {
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale-a.scale)), nil)
+	return factor.Mul(factor, a.value())
+}
+
+// Rescale returns a with scale decimal places. It returns an error if a has
+// non-zero digits beyond scale decimal places, rather than rounding.
+func (a Amount) Rescale(scale int) (Amount, error) {
+	if scale < 0 {
+		return Amount{}, fmt.Errorf("invalid scale %d", scale)
+	}
+	if scale >= a.scale {
+		return Amount{unscaled: a.rescale(scale), scale: scale}, nil
+	}
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(a.scale-scale)), nil)
+	q, r := new(big.Int).QuoRem(a.value(), factor, new(big.Int))
+	if r.Sign() != 0 {
+		return Amount{}, fmt.Errorf("amount %v has more than %d decimal places", a, scale)
+	}
+	return Amount{unscaled: q, scale: scale}, nil
+}
+
+// Cmp compares a and b numerically, returning -1 if a < b, 0 if a == b and +1
+// if a > b. Scale is ignored, so 1.5 and 1.50 are equal.
+func (a Amount) Cmp(b Amount) int {
+	scale := maxInt(a.scale, b.scale)
+	return a.rescale(scale).Cmp(b.rescale(scale))
+}
+
+// Add returns a + b, with the larger scale of the two.
+func (a Amount) Add(b Amount) Amount {
+	scale := maxInt(a.scale, b.scale)
+	return Amount{unscaled: new(big.Int).Add(a.rescale(scale), b.rescale(scale)), scale: scale}
+}
+
+// Sub returns a - b, with the larger scale of the two.
+func (a Amount) Sub(b Amount) Amount {
+	return a.Add(b.Neg())
+}
+
+// Neg returns -a.
+func (a Amount) Neg() Amount {
+	return Amount{unscaled: new(big.Int).Neg(a.value()), scale: a.scale}
+}
+
+// Mul returns a multiplied by n.
+func (a Amount) Mul(n int64) Amount {
+	return Amount{unscaled: new(big.Int).Mul(a.value(), big.NewInt(n)), scale: a.scale}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// MarshalJSON implements json.Marshaler, encoding a as a decimal string.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts a decimal string, or
+// a JSON number without an exponent.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Money is an Amount in a Currency. It is encoded in JSON as the amount and
+// currency objects used by Form3 resources, such as payment charges:
+//
+//	{"amount": "1234.50", "currency": "GBP"}
+//
+// Resources that hold the amount and currency as separate attributes should
+// use Amount and Currency fields instead, as embedding Money would replace
+// the encoding of the whole resource.
+//
+// Encoding or decoding fails if the currency is unknown or the amount has
+// more decimal places than the currency's minor units. The amount is encoded
+// with exactly the currency's minor units, e.g. "10.500" EUR as "10.50", so
+// that no excess precision is sent.
+type Money struct {
+	Amount   Amount   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// ErrCurrencyMismatch is returned by operations on Money in different
+// currencies.
+var ErrCurrencyMismatch = errors.New("money in different currencies")
+
+// NewMoney parses amount as an amount of currency.
+func NewMoney(amount string, currency Currency) (Money, error) {
+	a, err := ParseAmount(amount)
+	if err != nil {
+		return Money{}, err
+	}
+	m := Money{Amount: a, Currency: currency}
+	if err := m.Validate(); err != nil {
+		return Money{}, err
+	}
+	return m, nil
+}
+
+// Validate checks that the currency is known and the amount does not have
+// more decimal places than the currency allows.
+func (m Money) Validate() error {
+	units, ok := m.Currency.MinorUnits()
+	if !ok {
+		return fmt.Errorf("unknown currency %q", m.Currency)
+	}
+	if m.Amount.scale > units {
+		if _, err := m.Amount.Rescale(units); err != nil {
+			return fmt.Errorf("%v: %s allows %d decimal places", err, m.Currency, units)
+		}
+	}
+	return nil
+}
+
+// String returns m as the amount followed by the currency, e.g. "10.50 GBP".
+func (m Money) String() string {
+	return m.Amount.String() + " " + string(m.Currency)
+}
+
+// Add returns m + o. It returns ErrCurrencyMismatch if they are in different
+// currencies.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount.Add(o.Amount), Currency: m.Currency}, nil
+}
+
+// Sub returns m - o. It returns ErrCurrencyMismatch if they are in different
+// currencies.
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount.Sub(o.Amount), Currency: m.Currency}, nil
+}
+
+// Cmp compares the amounts of m and o as Amount.Cmp does. It returns
+// ErrCurrencyMismatch if they are in different currencies.
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency {
+		return 0, ErrCurrencyMismatch
+	}
+	return m.Amount.Cmp(o.Amount), nil
+}
+
+// moneyJSON has the fields of Money without its methods, to avoid recursion
+// when encoding.
+type moneyJSON Money
+
+// MarshalJSON implements json.Marshaler.
+func (m Money) MarshalJSON() ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	units, _ := m.Currency.MinorUnits()
+	amount, err := m.Amount.Rescale(units)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(moneyJSON{Amount: amount, Currency: m.Currency})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := Money(v).Validate(); err != nil {
+		return err
+	}
+	*m = Money(v)
+	return nil
+}
@@ -0,0 +1,191 @@
+package form3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestUnit_ParseAmount(t *testing.T) {
+	for _, s := range []string{"0", "10", "10.50", "-3.125", "0.01", "12345678901234567890.123456789"} {
+		a, err := ParseAmount(s)
+		if err != nil {
+			t.Errorf("ParseAmount(%q) returned error: %v", s, err)
+			continue
+		}
+		if got := a.String(); got != s {
+			t.Errorf("ParseAmount(%q).String() = %q, want %q", s, got, s)
+		}
+	}
+
+	for _, s := range []string{"", "-", ".5", "5.", "1e3", "1,000.00", "+1", "1.2.3", " 1"} {
+		if _, err := ParseAmount(s); err == nil {
+			t.Errorf("ParseAmount(%q) did not return error", s)
+		}
+	}
+}
+
+func TestUnit_Amount_Arithmetic(t *testing.T) {
+	a, b := MustParseAmount("10.5"), MustParseAmount("0.25")
+
+	if got, want := a.Add(b).String(), "10.75"; got != want {
+		t.Errorf("Add = %v, want %v", got, want)
+	}
+	if got, want := b.Sub(a).String(), "-10.25"; got != want {
+		t.Errorf("Sub = %v, want %v", got, want)
+	}
+	if got, want := b.Mul(3).String(), "0.75"; got != want {
+		t.Errorf("Mul = %v, want %v", got, want)
+	}
+	if got, want := MustParseAmount("0.1").Add(MustParseAmount("0.2")).Cmp(MustParseAmount("0.3")), 0; got != want {
+		t.Errorf("0.1 + 0.2 Cmp 0.3 = %v, want %v", got, want)
+	}
+	if got, want := MustParseAmount("1.5").Cmp(MustParseAmount("1.50")), 0; got != want {
+		t.Errorf("1.5 Cmp 1.50 = %v, want %v", got, want)
+	}
+	if got, want := a.Cmp(b), 1; got != want {
+		t.Errorf("10.5 Cmp 0.25 = %v, want %v", got, want)
+	}
+	if got, want := NewAmountFromMinorUnits(-5, 2).String(), "-0.05"; got != want {
+		t.Errorf("NewAmountFromMinorUnits(-5, 2) = %v, want %v", got, want)
+	}
+	if !(Amount{}).IsZero() || (Amount{}).String() != "0" {
+		t.Errorf("Zero Amount = %v, want 0", Amount{})
+	}
+}
+
+func TestUnit_Amount_Rescale(t *testing.T) {
+	a, err := MustParseAmount("10.500").Rescale(2)
+	if err != nil || a.String() != "10.50" {
+		t.Errorf("Rescale(2) = %v, %v, want 10.50", a, err)
+	}
+	a, err = MustParseAmount("10").Rescale(2)
+	if err != nil || a.String() != "10.00" {
+		t.Errorf("Rescale(2) = %v, %v, want 10.00", a, err)
+	}
+	if _, err := MustParseAmount("10.505").Rescale(2); err == nil {
+		t.Error("Rescale(2) of 10.505 did not return error")
+	}
+}
+
+func TestUnit_Money_Validate(t *testing.T) {
+	valid := []struct {
+		amount   string
+		currency Currency
+	}{
+		{"10.50", "GBP"},
+		{"1000", "JPY"},
+		{"1.125", "KWD"},
+		{"10.500", "EUR"}, // trailing zeros are dropped when encoded
+	}
+	for _, tt := range valid {
+		if _, err := NewMoney(tt.amount, tt.currency); err != nil {
+			t.Errorf("NewMoney(%q, %q) returned error: %v", tt.amount, tt.currency, err)
+		}
+	}
+
+	invalid := []struct {
+		amount   string
+		currency Currency
+	}{
+		{"10.505", "GBP"},
+		{"1000.5", "JPY"},
+		{"10.00", "XYZ"},
+		{"ten", "GBP"},
+	}
+	for _, tt := range invalid {
+		if _, err := NewMoney(tt.amount, tt.currency); err == nil {
+			t.Errorf("NewMoney(%q, %q) did not return error", tt.amount, tt.currency)
+		}
+	}
+}
+
+func TestUnit_Money_Arithmetic(t *testing.T) {
+	gbp, _ := NewMoney("10.00", "GBP")
+	fee, _ := NewMoney("0.35", "GBP")
+	eur, _ := NewMoney("10.00", "EUR")
+
+	total, err := gbp.Add(fee)
+	if err != nil || total.String() != "10.35 GBP" {
+		t.Errorf("Add = %v, %v, want 10.35 GBP", total, err)
+	}
+	if _, err := gbp.Sub(eur); err != ErrCurrencyMismatch {
+		t.Errorf("Sub in different currencies returned %v, want %v", err, ErrCurrencyMismatch)
+	}
+	if c, err := fee.Cmp(gbp); err != nil || c != -1 {
+		t.Errorf("Cmp = %v, %v, want -1", c, err)
+	}
+}
+
+func TestUnit_Money_JSON(t *testing.T) {
+	in := `{"amount":"1234.50","currency":"GBP"}`
+
+	m := new(Money)
+	if err := json.Unmarshal([]byte(in), m); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	out, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	if string(out) != in {
+		t.Errorf("Money round-tripped to %s, want %s", out, in)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount":"1.001","currency":"GBP"}`), m); err == nil {
+		t.Error("Unmarshal accepted excess precision")
+	}
+	if _, err := json.Marshal(Money{Amount: MustParseAmount("1.5"), Currency: "JPY"}); err == nil {
+		t.Error("Marshal accepted excess precision")
+	}
+	encoded := []struct {
+		amount   string
+		currency Currency
+		want     string
+	}{
+		{"10.500", "EUR", `{"amount":"10.50","currency":"EUR"}`},
+		{"10.5", "EUR", `{"amount":"10.50","currency":"EUR"}`},
+		{"1000.0", "JPY", `{"amount":"1000","currency":"JPY"}`},
+		{"1.1", "KWD", `{"amount":"1.100","currency":"KWD"}`},
+	}
+	for _, tt := range encoded {
+		out, err := json.Marshal(Money{Amount: MustParseAmount(tt.amount), Currency: tt.currency})
+		if err != nil {
+			t.Errorf("Marshal of %s %s returned error: %v", tt.amount, tt.currency, err)
+			continue
+		}
+		if string(out) != tt.want {
+			t.Errorf("Marshal of %s %s = %s, want %s", tt.amount, tt.currency, out, tt.want)
+		}
+	}
+}
+
+func TestUnit_Money_Do(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	type charges struct {
+		Amount       Amount   `json:"amount"`
+		Currency     Currency `json:"currency"`
+		SenderCharge []Money  `json:"sender_charges"`
+	}
+
+	mux.HandleFunc("/transaction/payments/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"amount": "99999999999999999.99", "currency": "GBP", "sender_charges": [{"amount": "5.00", "currency": "GBP"}]}`)
+	})
+
+	req, _ := client.NewRequest("GET", "transaction/payments/1", nil)
+	got := new(charges)
+	if _, err := client.Do(context.Background(), req, got); err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+
+	if got, want := got.Amount.String(), "99999999999999999.99"; got != want {
+		t.Errorf("Decoded amount %v, want %v", got, want)
+	}
+	if len(got.SenderCharge) != 1 || got.SenderCharge[0].String() != "5.00 GBP" {
+		t.Errorf("Decoded sender charges %v, want [5.00 GBP]", got.SenderCharge)
+	}
+}
@@ -0,0 +1,141 @@
+package form3
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// NormaliseAccountAttributes returns a copy of attributes with the
+// deprecated title, first_name, bank_account_name and
+// alternative_bank_account_names attributes replaced by name and
+// alternative_names, together with warnings describing any information that
+// was changed or dropped. attributes is not modified.
+//
+// If name is empty it is taken from bank_account_name or, failing that, from
+// title and first_name, and split on word boundaries into lines of at most
+// 140 characters, keeping up to four lines. If alternative_names is empty it
+// is taken from alternative_bank_account_names, one name per line, keeping up
+// to three names. Deprecated attributes that are not needed because the new
+// attributes are already set are dropped.
+func NormaliseAccountAttributes(attributes *AccountAttributes) (*AccountAttributes, []string) {
+	if attributes == nil {
+		return nil, nil
+	}
+	a := *attributes
+	var warnings []string
+	warn := func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+
+	legacyName := strings.TrimSpace(stringValue(a.BankAccountName))
+	personName := strings.TrimSpace(strings.Join([]string{stringValue(a.Title), stringValue(a.FirstName)}, " "))
+	switch {
+	case len(a.Name) > 0:
+		if legacyName != "" || personName != "" {
+			warn("title, first_name and bank_account_name dropped as name is set")
+		}
+	case legacyName != "":
+		a.Name = splitNameLines(legacyName, maxNameLines, warn)
+		if personName != "" {
+			warn("title and first_name dropped as name is taken from bank_account_name")
+		}
+	case personName != "":
+		a.Name = splitNameLines(personName, maxNameLines, warn)
+		warn("name taken from title and first_name, which may not be the full account name")
+	}
+
+	if legacyAlternatives := strings.TrimSpace(stringValue(a.AlternativeBankAccountNames)); legacyAlternatives != "" {
+		if len(a.AlternativeNames) > 0 {
+			warn("alternative_bank_account_names dropped as alternative_names is set")
+		} else {
+			a.AlternativeNames = splitAlternativeNames(legacyAlternatives, warn)
+		}
+	}
+
+	a.Title, a.FirstName, a.BankAccountName, a.AlternativeBankAccountNames = nil, nil, nil, nil
+	return &a, warnings
+}
+
+// splitNameLines splits name on word boundaries into at most maxLines lines
+// of at most maxNameLength characters. Words longer than a line are broken.
+func splitNameLines(name string, maxLines int, warn func(string, ...interface{})) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(name) {
+		for utf8.RuneCountInString(word) > maxNameLength {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			head, rest := splitAtRune(word, maxNameLength)
+			lines = append(lines, head)
+			word = rest
+		}
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= maxNameLength:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	if len(lines) > maxLines {
+		warn("name truncated to %d lines of %d characters", maxLines, maxNameLength)
+		lines = lines[:maxLines]
+	}
+	return lines
+}
+
+// splitAlternativeNames splits legacy alternative names, one per line, into
+// at most three names of at most maxNameLength characters.
+func splitAlternativeNames(names string, warn func(string, ...interface{})) []string {
+	var result []string
+	for _, name := range strings.Split(names, "\n") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if utf8.RuneCountInString(name) > maxNameLength {
+			warn("alternative name %q truncated to %d characters", name, maxNameLength)
+			head, _ := splitAtRune(name, maxNameLength)
+			name = strings.TrimSpace(head)
+		}
+		result = append(result, name)
+	}
+	if len(result) > maxAlternativeNames {
+		warn("alternative_names truncated to %d names", maxAlternativeNames)
+		result = result[:maxAlternativeNames]
+	}
+	return result
+}
+
+// normaliseAccount returns a copy of account with normalised attributes if
+// the client normalises deprecated attributes.
+func (s *AccountsService) normaliseAccount(account *Account) (*Account, []string) {
+	if !s.client.NormaliseDeprecatedAttributes || account == nil || account.Attributes == nil {
+		return account, nil
+	}
+	a := *account
+	var warnings []string
+	a.Attributes, warnings = NormaliseAccountAttributes(account.Attributes)
+	return &a, warnings
+}
+
+// splitAtRune splits s after its first n characters, so that no multi-byte
+// character is broken.
+func splitAtRune(s string, n int) (head, rest string) {
+	for i := range s {
+		if n == 0 {
+			return s[:i], s[i:]
+		}
+		n--
+	}
+	return s, ""
+}
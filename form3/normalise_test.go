@@ -0,0 +1,163 @@
+package form3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestUnit_NormaliseAccountAttributes(t *testing.T) {
+	in := &AccountAttributes{
+		Country:                     CountryGB.Ptr(),
+		Title:                       String("Ms"),
+		FirstName:                   String("Samantha"),
+		BankAccountName:             String("Samantha Holder"),
+		AlternativeBankAccountNames: String("Sam Holder\nS Holder"),
+	}
+	original := *in
+
+	got, warnings := NormaliseAccountAttributes(in)
+
+	want := &AccountAttributes{
+		Country:          CountryGB.Ptr(),
+		Name:             []string{"Samantha Holder"},
+		AlternativeNames: []string{"Sam Holder", "S Holder"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormaliseAccountAttributes = %+v, want %+v", got, want)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "title and first_name dropped") {
+		t.Errorf("NormaliseAccountAttributes warnings = %q, want title and first_name dropped", warnings)
+	}
+	if !reflect.DeepEqual(*in, original) {
+		t.Error("NormaliseAccountAttributes modified its argument")
+	}
+}
+
+func TestUnit_NormaliseAccountAttributes_FromPersonName(t *testing.T) {
+	got, warnings := NormaliseAccountAttributes(&AccountAttributes{Title: String("Dr"), FirstName: String("Samantha")})
+
+	if want := []string{"Dr Samantha"}; !reflect.DeepEqual(got.Name, want) {
+		t.Errorf("Name = %q, want %q", got.Name, want)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("Warnings = %q, want 1 warning", warnings)
+	}
+}
+
+func TestUnit_NormaliseAccountAttributes_SplitsLongNames(t *testing.T) {
+	word := strings.Repeat("a", 60)
+	long := strings.TrimSpace(strings.Repeat(word+" ", 12)) // 12 words fit 2 to a line
+
+	got, warnings := NormaliseAccountAttributes(&AccountAttributes{BankAccountName: String(long)})
+
+	if len(got.Name) != maxNameLines {
+		t.Fatalf("Name has %d lines, want %d", len(got.Name), maxNameLines)
+	}
+	for i, line := range got.Name {
+		if line != word+" "+word {
+			t.Errorf("Name line %d = %q, want two words", i+1, line)
+		}
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "truncated") {
+		t.Errorf("Warnings = %q, want truncation warning", warnings)
+	}
+}
+
+func TestUnit_NormaliseAccountAttributes_MultiByteNames(t *testing.T) {
+	word := strings.Repeat("é", maxNameLength+10)
+	alternative := strings.Repeat("ø", maxNameLength+1)
+
+	got, _ := NormaliseAccountAttributes(&AccountAttributes{
+		BankAccountName:             String("Zoë " + word),
+		AlternativeBankAccountNames: String(alternative),
+	})
+
+	want := []string{"Zoë", strings.Repeat("é", maxNameLength), strings.Repeat("é", 10)}
+	if !reflect.DeepEqual(got.Name, want) {
+		t.Errorf("Name = %q, want %q", got.Name, want)
+	}
+	if want := []string{strings.Repeat("ø", maxNameLength)}; !reflect.DeepEqual(got.AlternativeNames, want) {
+		t.Errorf("AlternativeNames = %q, want %q", got.AlternativeNames, want)
+	}
+	for _, name := range append(got.Name, got.AlternativeNames...) {
+		if !utf8.ValidString(name) {
+			t.Errorf("Name %q is not valid UTF-8", name)
+		}
+	}
+}
+
+func TestUnit_NormaliseAccountAttributes_KeepsNewAttributes(t *testing.T) {
+	got, warnings := NormaliseAccountAttributes(&AccountAttributes{
+		Name:                        []string{"Samantha Holder"},
+		BankAccountName:             String("S Holder"),
+		AlternativeNames:            []string{"Sam Holder"},
+		AlternativeBankAccountNames: String("S H"),
+	})
+
+	want := &AccountAttributes{Name: []string{"Samantha Holder"}, AlternativeNames: []string{"Sam Holder"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormaliseAccountAttributes = %+v, want %+v", got, want)
+	}
+	if len(warnings) != 2 {
+		t.Errorf("Warnings = %q, want 2 warnings", warnings)
+	}
+}
+
+func TestUnit_AccountsService_Create_Normalise(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi(WithDeprecatedAttributeNormalisation())
+	defer teardown()
+
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		got := new(AccountCreation)
+		json.NewDecoder(r.Body).Decode(got)
+		if got.Data.Attributes.BankAccountName != nil || got.Data.Attributes.FirstName != nil {
+			t.Errorf("Request has deprecated attributes: %+v", got.Data.Attributes)
+		}
+		if want := []string{"Samantha Holder"}; !reflect.DeepEqual(got.Data.Attributes.Name, want) {
+			t.Errorf("Request name = %q, want %q", got.Data.Attributes.Name, want)
+		}
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+
+	_, resp, err := client.Accounts.Create(context.Background(), &Account{
+		Attributes: &AccountAttributes{FirstName: String("Sam"), BankAccountName: String("Samantha Holder")},
+	})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if len(resp.Warnings) != 1 {
+		t.Errorf("Response warnings = %q, want 1 warning", resp.Warnings)
+	}
+}
+
+func TestUnit_AccountsService_Update_NotNormalisedByDefault(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		got := new(AccountUpdate)
+		json.NewDecoder(r.Body).Decode(got)
+		if got.Data.Attributes.BankAccountName == nil {
+			t.Error("Request deprecated attributes were normalised")
+		}
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+
+	_, resp, err := client.Accounts.Update(context.Background(), &Account{
+		ID:         String("1"),
+		Version:    Int(0),
+		Attributes: &AccountAttributes{BankAccountName: String("Samantha Holder")},
+	})
+	if err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if resp.Warnings != nil {
+		t.Errorf("Response warnings = %q, want none", resp.Warnings)
+	}
+}
@@ -0,0 +1,23 @@
+package form3
+
+import "context"
+
+type operationKey struct{}
+
+// withOperation returns a copy of ctx that names the API operation, such as
+// "accounts.create", that a request made with it performs.
+func withOperation(ctx context.Context, operation string) context.Context {
+	if ctx == nil {
+		return nil
+	}
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// OperationFromContext returns the name of the API operation being performed
+// with ctx, in the form "<resource>.<operation>", e.g. "accounts.fetch". The
+// context of every request sent by a service method carries its operation
+// name, so it can be used by instrumentation wrapping the HTTP client.
+func OperationFromContext(ctx context.Context) (string, bool) {
+	operation, ok := ctx.Value(operationKey{}).(string)
+	return operation, ok
+}
@@ -0,0 +1,288 @@
+package form3
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// An Environment identifies a Form3 API deployment.
+type Environment string
+
+// Environments with known base URLs.
+const (
+	EnvironmentProduction Environment = "production"
+	EnvironmentStaging    Environment = "staging"
+	EnvironmentLocal      Environment = "local" // the fake account API from docker-compose.yml
+)
+
+var environmentBaseURLs = map[Environment]string{
+	EnvironmentProduction: "https://api.form3.tech/v1/",
+	EnvironmentStaging:    "https://api.staging-form3.tech/v1/",
+	EnvironmentLocal:      "http://localhost:8080/v1/",
+}
+
+// BaseURL returns the base URL of the API in the environment, or an empty
+// string if the environment is not known.
+func (e Environment) BaseURL() string {
+	return environmentBaseURLs[e]
+}
+
+// Environment variables read by NewClientFromEnv.
+const (
+	EnvBaseURL        = "FORM3_BASE_URL"
+	EnvEnvironment    = "FORM3_ENVIRONMENT"
+	EnvTimeout        = "FORM3_TIMEOUT"
+	EnvUserAgent      = "FORM3_USER_AGENT"
+	EnvOrganisationID = "FORM3_ORGANISATION_ID"
+	EnvClientID       = "FORM3_CLIENT_ID"
+	EnvClientSecret   = "FORM3_CLIENT_SECRET"
+)
+
+// clientConfig collects the settings of a Client made by options, so they can
+// be validated together before the Client is created.
+type clientConfig struct {
+	httpClient     *http.Client
+	middleware     []Middleware
+	baseURL        string
+	environment    Environment
+	timeout        time.Duration
+	userAgent      *string
+	organisationID string
+	credentials    CredentialsProvider
+	normalise      bool
+}
+
+// An Option configures a Client created with NewClientWithOptions.
+type Option func(*clientConfig) error
+
+// NewClientWithOptions returns a new Form3 API client configured by opts.
+// Without options it is equivalent to NewClient(nil). An error is returned if
+// an option is invalid or options conflict, such as giving both a base URL
+// and an environment.
+func NewClientWithOptions(opts ...Option) (*Client, error) {
+	cfg := new(clientConfig)
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.baseURL != "" && cfg.environment != "" {
+		return nil, errors.New("a base URL and an environment cannot both be given")
+	}
+	baseURL := cfg.baseURL
+	if cfg.environment != "" {
+		baseURL = cfg.environment.BaseURL()
+	}
+
+	httpClient := cfg.httpClient
+	if cfg.timeout > 0 {
+		// Copy the client so that the caller's is not modified.
+		hc := http.Client{}
+		if httpClient != nil {
+			hc = *httpClient
+		}
+		hc.Timeout = cfg.timeout
+		httpClient = &hc
+	}
+
+	c := NewClient(httpClient)
+	if baseURL != "" {
+		u, err := parseBaseURL(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		c.BaseURL = u
+	}
+	if cfg.userAgent != nil {
+		c.UserAgent = *cfg.userAgent
+	}
+	c.OrganisationID = cfg.organisationID
+	c.NormaliseDeprecatedAttributes = cfg.normalise
+	if cfg.credentials != nil {
+		c.setCredentialsProvider(cfg.credentials)
+	}
+	c.middleware = cfg.middleware
+	c.doer = chain(authorizer(c.client), c.middleware)
+	return c, nil
+}
+
+// NewClientFromEnv returns a new Form3 API client configured from FORM3_*
+// environment variables, followed by opts:
+//
+//	FORM3_BASE_URL         base URL of the API, with a trailing slash
+//	FORM3_ENVIRONMENT      production, staging or local, instead of FORM3_BASE_URL
+//	FORM3_TIMEOUT          HTTP client timeout, e.g. 30s
+//	FORM3_USER_AGENT       user agent sent with requests
+//	FORM3_ORGANISATION_ID  organisation ID of new resources
+//	FORM3_CLIENT_ID        OAuth client ID, requires FORM3_CLIENT_SECRET
+//	FORM3_CLIENT_SECRET    OAuth client secret, requires FORM3_CLIENT_ID
+//
+// Unset and empty variables are ignored.
+func NewClientFromEnv(opts ...Option) (*Client, error) {
+	var envOpts []Option
+	if v := os.Getenv(EnvBaseURL); v != "" {
+		envOpts = append(envOpts, WithBaseURL(v))
+	}
+	if v := os.Getenv(EnvEnvironment); v != "" {
+		envOpts = append(envOpts, WithEnvironment(Environment(v)))
+	}
+	if v := os.Getenv(EnvTimeout); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", EnvTimeout, err)
+		}
+		envOpts = append(envOpts, WithTimeout(timeout))
+	}
+	if v := os.Getenv(EnvUserAgent); v != "" {
+		envOpts = append(envOpts, WithUserAgent(v))
+	}
+	if v := os.Getenv(EnvOrganisationID); v != "" {
+		envOpts = append(envOpts, WithOrganisationID(v))
+	}
+	clientID, clientSecret := os.Getenv(EnvClientID), os.Getenv(EnvClientSecret)
+	if clientID != "" || clientSecret != "" {
+		envOpts = append(envOpts, WithCredentials(Credentials{ClientID: clientID, ClientSecret: clientSecret}))
+	}
+
+	return NewClientWithOptions(append(envOpts, opts...)...)
+}
+
+// parseBaseURL parses and checks a base URL for API requests.
+func parseBaseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("base URL %q must use http or https", s)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("base URL %q has no host", s)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		return nil, fmt.Errorf("base URL must have a trailing slash, but %q does not", s)
+	}
+	return u, nil
+}
+
+// WithHTTPClient sets the HTTP client used to communicate with the API.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(cfg *clientConfig) error {
+		if httpClient == nil {
+			return errors.New("HTTP client must be non-nil")
+		}
+		cfg.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithMiddleware adds middleware around the HTTP client used to communicate
+// with the API. Middleware from all WithMiddleware options is applied in the
+// order given, the first being the outermost.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(cfg *clientConfig) error {
+		for _, mw := range middleware {
+			if mw == nil {
+				return errors.New("middleware must be non-nil")
+			}
+		}
+		cfg.middleware = append(cfg.middleware, middleware...)
+		return nil
+	}
+}
+
+// WithBaseURL sets the base URL for API requests. It must have a trailing
+// slash, e.g. "https://api.form3.tech/v1/".
+func WithBaseURL(baseURL string) Option {
+	return func(cfg *clientConfig) error {
+		if _, err := parseBaseURL(baseURL); err != nil {
+			return err
+		}
+		cfg.baseURL = baseURL
+		return nil
+	}
+}
+
+// WithEnvironment sets the base URL for API requests to that of a known
+// environment.
+func WithEnvironment(environment Environment) Option {
+	return func(cfg *clientConfig) error {
+		if environment.BaseURL() == "" {
+			return fmt.Errorf("unknown environment %q", environment)
+		}
+		cfg.environment = environment
+		return nil
+	}
+}
+
+// WithTimeout sets the time limit for requests made by the HTTP client,
+// including reading the response body.
+func WithTimeout(timeout time.Duration) Option {
+	return func(cfg *clientConfig) error {
+		if timeout <= 0 {
+			return fmt.Errorf("timeout must be positive, got %v", timeout)
+		}
+		cfg.timeout = timeout
+		return nil
+	}
+}
+
+// WithUserAgent sets the user agent sent with requests. An empty user agent
+// stops the header being sent.
+func WithUserAgent(userAgent string) Option {
+	return func(cfg *clientConfig) error {
+		cfg.userAgent = &userAgent
+		return nil
+	}
+}
+
+// WithOrganisationID sets the organisation ID assigned to new resources that
+// do not specify one.
+func WithOrganisationID(organisationID string) Option {
+	return func(cfg *clientConfig) error {
+		if organisationID == "" {
+			return errors.New("organisation ID must be non-empty")
+		}
+		cfg.organisationID = organisationID
+		return nil
+	}
+}
+
+// WithCredentials sets the credentials the client authenticates with.
+func WithCredentials(credentials Credentials) Option {
+	return func(cfg *clientConfig) error {
+		if err := credentials.validate(); err != nil {
+			return err
+		}
+		cfg.credentials = StaticCredentials(credentials)
+		return nil
+	}
+}
+
+// WithCredentialsProvider makes the client authenticate each request with
+// the credentials provided by p at the time, so that they can be rotated.
+func WithCredentialsProvider(p CredentialsProvider) Option {
+	return func(cfg *clientConfig) error {
+		if p == nil {
+			return errors.New("credentials provider must be non-nil")
+		}
+		cfg.credentials = p
+		return nil
+	}
+}
+
+// WithDeprecatedAttributeNormalisation makes the client replace deprecated
+// account attributes by their successors before accounts are created or
+// updated. See NormaliseAccountAttributes.
+func WithDeprecatedAttributeNormalisation() Option {
+	return func(cfg *clientConfig) error {
+		cfg.normalise = true
+		return nil
+	}
+}
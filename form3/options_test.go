@@ -0,0 +1,154 @@
+package form3
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUnit_NewClientWithOptions(t *testing.T) {
+	c, err := NewClientWithOptions()
+	if err != nil {
+		t.Fatalf("NewClientWithOptions returned error: %v", err)
+	}
+
+	if got, want := c.BaseURL.String(), defaultBaseURL; got != want {
+		t.Errorf("NewClientWithOptions BaseURL is %v, want %v", got, want)
+	}
+	if got, want := c.UserAgent, userAgent; got != want {
+		t.Errorf("NewClientWithOptions UserAgent is %v, want %v", got, want)
+	}
+}
+
+func TestUnit_NewClientWithOptions_HTTPClient(t *testing.T) {
+	httpClient := &http.Client{}
+	c, err := NewClientWithOptions(WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("NewClientWithOptions returned error: %v", err)
+	}
+	if c.client != httpClient {
+		t.Error("NewClientWithOptions did not use the HTTP client provided")
+	}
+
+	if _, err := NewClientWithOptions(WithHTTPClient(nil)); err == nil {
+		t.Error("NewClientWithOptions accepted a nil HTTP client")
+	}
+}
+
+func TestUnit_NewClientWithOptions_NilMiddleware(t *testing.T) {
+	if _, err := NewClientWithOptions(WithMiddleware(nil)); err == nil {
+		t.Error("NewClientWithOptions accepted nil middleware")
+	}
+}
+
+func TestUnit_NewClientWithOptions_Settings(t *testing.T) {
+	c, err := NewClientWithOptions(
+		WithBaseURL("http://accountapi:8080/v1/"),
+		WithUserAgent("reconciler/1.0"),
+		WithOrganisationID("eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"),
+	)
+	if err != nil {
+		t.Fatalf("NewClientWithOptions returned error: %v", err)
+	}
+
+	if got, want := c.BaseURL.String(), "http://accountapi:8080/v1/"; got != want {
+		t.Errorf("BaseURL is %v, want %v", got, want)
+	}
+	if got, want := c.UserAgent, "reconciler/1.0"; got != want {
+		t.Errorf("UserAgent is %v, want %v", got, want)
+	}
+	if got, want := c.OrganisationID, "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"; got != want {
+		t.Errorf("OrganisationID is %v, want %v", got, want)
+	}
+}
+
+func TestUnit_NewClientWithOptions_Environment(t *testing.T) {
+	c, err := NewClientWithOptions(WithEnvironment(EnvironmentStaging))
+	if err != nil {
+		t.Fatalf("NewClientWithOptions returned error: %v", err)
+	}
+	if got, want := c.BaseURL.String(), "https://api.staging-form3.tech/v1/"; got != want {
+		t.Errorf("BaseURL is %v, want %v", got, want)
+	}
+}
+
+func TestUnit_NewClientWithOptions_Timeout(t *testing.T) {
+	httpClient := &http.Client{}
+	c, err := NewClientWithOptions(WithHTTPClient(httpClient), WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("NewClientWithOptions returned error: %v", err)
+	}
+	if got, want := c.client.Timeout, 5*time.Second; got != want {
+		t.Errorf("HTTP client timeout is %v, want %v", got, want)
+	}
+	if httpClient.Timeout != 0 {
+		t.Error("NewClientWithOptions modified the HTTP client provided")
+	}
+}
+
+func TestUnit_NewClientWithOptions_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"base URL without trailing slash", []Option{WithBaseURL("https://api.form3.tech/v1")}},
+		{"base URL without scheme", []Option{WithBaseURL("api.form3.tech/v1/")}},
+		{"base URL and environment", []Option{WithBaseURL("https://api.form3.tech/v1/"), WithEnvironment(EnvironmentLocal)}},
+		{"unknown environment", []Option{WithEnvironment("sandbox")}},
+		{"negative timeout", []Option{WithTimeout(-time.Second)}},
+		{"empty organisation ID", []Option{WithOrganisationID("")}},
+		{"client ID without secret", []Option{WithCredentials(Credentials{ClientID: "client"})}},
+		{"signing key ID without signer", []Option{WithCredentials(Credentials{SigningKeyID: "key"})}},
+		{"nil credentials provider", []Option{WithCredentialsProvider(nil)}},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewClientWithOptions(tt.opts...); err == nil {
+			t.Errorf("NewClientWithOptions with %s did not return error", tt.name)
+		}
+	}
+}
+
+func TestUnit_NewClientFromEnv(t *testing.T) {
+	t.Setenv(EnvEnvironment, "local")
+	t.Setenv(EnvTimeout, "10s")
+	t.Setenv(EnvUserAgent, "reconciler/1.0")
+	t.Setenv(EnvOrganisationID, "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c")
+	t.Setenv(EnvClientID, "client")
+	t.Setenv(EnvClientSecret, "secret")
+
+	c, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv returned error: %v", err)
+	}
+
+	if got, want := c.BaseURL.String(), "http://localhost:8080/v1/"; got != want {
+		t.Errorf("BaseURL is %v, want %v", got, want)
+	}
+	if got, want := c.client.Timeout, 10*time.Second; got != want {
+		t.Errorf("HTTP client timeout is %v, want %v", got, want)
+	}
+	if got, want := c.UserAgent, "reconciler/1.0"; got != want {
+		t.Errorf("UserAgent is %v, want %v", got, want)
+	}
+	if got, want := c.OrganisationID, "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"; got != want {
+		t.Errorf("OrganisationID is %v, want %v", got, want)
+	}
+	if credentials, _ := c.credentials.Credentials(context.Background()); credentials == nil || *credentials != (Credentials{ClientID: "client", ClientSecret: "secret"}) {
+		t.Errorf("Client credentials not set from environment")
+	}
+}
+
+func TestUnit_NewClientFromEnv_Invalid(t *testing.T) {
+	t.Setenv(EnvBaseURL, "http://localhost:8080/v1")
+	if _, err := NewClientFromEnv(); err == nil {
+		t.Errorf("NewClientFromEnv accepted %s without trailing slash", EnvBaseURL)
+	}
+
+	t.Setenv(EnvBaseURL, "")
+	t.Setenv(EnvTimeout, "ten seconds")
+	if _, err := NewClientFromEnv(); err == nil {
+		t.Errorf("NewClientFromEnv accepted invalid %s", EnvTimeout)
+	}
+}
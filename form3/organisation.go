@@ -0,0 +1,54 @@
+package form3
+
+import (
+	"fmt"
+)
+
+// ForOrganisation returns a view of c scoped to the organisation with the
+// given ID, for processes that operate several organisations. Resources
+// created through the view are assigned to the organisation, and must not
+// belong to another; listings through the view are restricted to it.
+//
+// The view shares c's HTTP client and middleware, and so its connection
+// pool, rate limiter and cache. If credentials is not nil, the view
+// authenticates with them instead of c's credentials.
+func (c *Client) ForOrganisation(organisationID string, credentials *Credentials) *Client {
+	v := *c
+	v.OrganisationID = organisationID
+	v.organisationScope = organisationID
+	if credentials != nil {
+		v.setCredentialsProvider(StaticCredentials(*credentials))
+	}
+	v.common.client = &v
+	v.Accounts = (*AccountsService)(&v.common)
+	return &v
+}
+
+// checkOrganisation returns an error if account belongs to an organisation
+// other than the one c is scoped to.
+func (c *Client) checkOrganisation(account *Account) error {
+	if c.organisationScope == "" || account.OrganisationId == nil || *account.OrganisationId == c.organisationScope {
+		return nil
+	}
+	return fmt.Errorf("account belongs to organisation %s, but the client is scoped to organisation %s", *account.OrganisationId, c.organisationScope)
+}
+
+// scopeListOptions returns opts with its filter restricted to the
+// organisation the client is scoped to, if any.
+func (s *AccountsService) scopeListOptions(opts *AccountListOptions) *AccountListOptions {
+	scope := s.client.organisationScope
+	if scope == "" {
+		return opts
+	}
+	o := AccountListOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	filter := AccountFilter{}
+	if o.Filter != nil {
+		filter = *o.Filter
+	}
+	filter.OrganisationID = scope
+	o.Filter = &filter
+	return &o
+}
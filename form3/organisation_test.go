@@ -0,0 +1,169 @@
+package form3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnit_Client_ForOrganisation_Create(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi(WithOrganisationID("parent"))
+	defer teardown()
+
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		body := new(AccountCreation)
+		json.NewDecoder(r.Body).Decode(body)
+		if got := stringValue(body.Data.OrganisationId); got != "tenant" {
+			t.Errorf("Account created in organisation %q, want tenant", got)
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(body)
+	})
+
+	view := client.ForOrganisation("tenant", nil)
+	if _, _, err := view.Accounts.Create(context.Background(), &Account{}); err != nil {
+		t.Fatalf("Accounts.Create returned error: %v", err)
+	}
+
+	_, _, err := view.Accounts.Create(context.Background(), &Account{OrganisationId: String("other")})
+	if err == nil {
+		t.Errorf("Expected error creating an account in another organisation")
+	}
+	_, _, err = view.Accounts.Update(context.Background(), &Account{ID: String("1"), Version: Int(0), OrganisationId: String("other")})
+	if err == nil {
+		t.Errorf("Expected error updating an account in another organisation")
+	}
+
+	if client.OrganisationID != "parent" {
+		t.Errorf("ForOrganisation changed the parent's organisation to %v", client.OrganisationID)
+	}
+}
+
+func TestUnit_Client_ForOrganisation_List(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	want := values{}
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		testFormValues(t, r, want)
+		fmt.Fprint(w, `{"data": []}`)
+	})
+
+	view := client.ForOrganisation("tenant", nil)
+	want = values{"filter[organisation_id]": "tenant", "filter[country]": "GB"}
+	opts := &AccountListOptions{Filter: &AccountFilter{Country: CountryGB}}
+	if _, _, err := view.Accounts.ListWithOptions(context.Background(), opts); err != nil {
+		t.Fatalf("Accounts.List returned error: %v", err)
+	}
+	if opts.Filter.OrganisationID != "" {
+		t.Errorf("List modified the caller's filter")
+	}
+
+	want = values{"filter[organisation_id]": "tenant"}
+	if _, _, err := view.Accounts.ListStream(context.Background(), nil, func(*Account) error { return nil }); err != nil {
+		t.Fatalf("Accounts.ListStream returned error: %v", err)
+	}
+
+	want = values{}
+	if _, _, err := client.Accounts.List(context.Background(), nil); err != nil {
+		t.Fatalf("Accounts.List returned error: %v", err)
+	}
+}
+
+func TestUnit_Client_ForOrganisation_SharesMiddleware(t *testing.T) {
+	requests := 0
+	counter := func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			requests++
+			return next.Do(req)
+		})
+	}
+	client, mux, _, teardown := setupClientWithStubbedApi(WithMiddleware(counter))
+	defer teardown()
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+
+	a, b := client.ForOrganisation("a", nil), client.ForOrganisation("b", nil)
+	for _, c := range []*Client{client, a, b} {
+		if _, _, err := c.Accounts.Fetch(context.Background(), "1"); err != nil {
+			t.Fatalf("Accounts.Fetch returned error: %v", err)
+		}
+	}
+	if requests != 3 {
+		t.Errorf("Shared middleware saw %d requests, want 3", requests)
+	}
+	if a.client != client.client {
+		t.Errorf("View does not share the parent's HTTP client")
+	}
+}
+
+func TestUnit_Client_ForOrganisation_Credentials(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi(WithCredentials(Credentials{ClientID: "parent", ClientSecret: "secret"}))
+	defer teardown()
+
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		id, _, _ := r.BasicAuth()
+		fmt.Fprintf(w, `{"access_token": "token-%s", "token_type": "bearer", "expires_in": 3600}`, id)
+	})
+	var authorization string
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+
+	tests := []struct {
+		client *Client
+		want   string
+	}{
+		{client.ForOrganisation("tenant", &Credentials{ClientID: "tenant", ClientSecret: "secret"}), "Bearer token-tenant"},
+		{client.ForOrganisation("shared", nil), "Bearer token-parent"},
+		{client, "Bearer token-parent"},
+	}
+	for _, tt := range tests {
+		if _, _, err := tt.client.Accounts.Fetch(context.Background(), "1"); err != nil {
+			t.Fatalf("Accounts.Fetch returned error: %v", err)
+		}
+		if authorization != tt.want {
+			t.Errorf("Authorization = %q, want %q", authorization, tt.want)
+		}
+	}
+}
+
+func TestUnit_Client_ForOrganisation_SharedCache(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi(WithMiddleware(CacheMiddleware(CacheOptions{TTL: time.Hour})))
+	defer teardown()
+
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		id, _, _ := r.BasicAuth()
+		fmt.Fprintf(w, `{"access_token": "token-%s", "token_type": "bearer", "expires_in": 3600}`, id)
+	})
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		organisation := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer token-")
+		fmt.Fprintf(w, `{"data": {"id": "1", "organisation_id": %q}}`, organisation)
+	})
+
+	a := client.ForOrganisation("a", &Credentials{ClientID: "a", ClientSecret: "secret"})
+	b := client.ForOrganisation("b", &Credentials{ClientID: "b", ClientSecret: "secret"})
+	for i := 0; i < 2; i++ {
+		for _, tt := range []struct {
+			client *Client
+			want   string
+		}{{a, "a"}, {b, "b"}} {
+			account, resp, err := tt.client.Accounts.Fetch(context.Background(), "1")
+			if err != nil {
+				t.Fatalf("Accounts.Fetch returned error: %v", err)
+			}
+			if got := stringValue(account.Data.OrganisationId); got != tt.want {
+				t.Errorf("View %s fetched the account of organisation %s", tt.want, got)
+			}
+			if fromCache := resp.Header.Get(cacheHeader) != ""; fromCache != (i > 0) {
+				t.Errorf("Fetch %d through view %s served from cache: %v", i, tt.want, fromCache)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package form3
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A RateLimiter limits the rate of requests using a token bucket. It is safe
+// for concurrent use, and may be shared by several clients so that they
+// respect a common limit.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64 // maximum number of tokens
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter allowing requestsPerSecond requests
+// per second on average, with bursts of up to burst requests. A
+// non-positive requestsPerSecond places no limit on requests.
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a request may be made, or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+	if err := sleep(ctx, wait); err != nil {
+		// Give back the token reserved for the abandoned request.
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// RateLimitMiddleware returns a Middleware that waits for limiter before
+// sending each request.
+func RateLimitMiddleware(limiter *RateLimiter) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if err := limiter.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+			return next.Do(req)
+		})
+	}
+}
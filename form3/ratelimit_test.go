@@ -0,0 +1,60 @@
+package form3
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUnit_RateLimiter_Burst(t *testing.T) {
+	limiter := NewRateLimiter(1, 3)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait returned error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Burst of 3 took %v, want no waiting", elapsed)
+	}
+}
+
+func TestUnit_RateLimiter_Waits(t *testing.T) {
+	limiter := NewRateLimiter(50, 1)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait returned error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("3 requests at 50/s took %v, want at least 40ms", elapsed)
+	}
+}
+
+func TestUnit_RateLimiter_Cancelled(t *testing.T) {
+	limiter := NewRateLimiter(0.001, 1)
+	limiter.Wait(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait returned %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestUnit_RateLimitMiddleware(t *testing.T) {
+	limiter := NewRateLimiter(0.001, 1)
+	client, _, _, teardown := setupClientWithStubbedApi(WithMiddleware(RateLimitMiddleware(limiter)))
+	defer teardown()
+
+	limiter.Wait(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := client.Accounts.Fetch(ctx, "1"); err != context.DeadlineExceeded {
+		t.Errorf("Fetch returned %v, want %v", err, context.DeadlineExceeded)
+	}
+}
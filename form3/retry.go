@@ -0,0 +1,131 @@
+package form3
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retry defaults used for zero fields of a RetryPolicy.
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryMinBackoff  = 100 * time.Millisecond
+	defaultRetryMaxBackoff  = 5 * time.Second
+)
+
+// A RetryPolicy configures RetryMiddleware. Zero fields take default values.
+type RetryPolicy struct {
+	// Maximum number of attempts, including the first. Defaults to 3.
+	MaxAttempts int
+
+	// Backoff before the first retry, doubled for each further retry.
+	// Defaults to 100ms.
+	MinBackoff time.Duration
+
+	// Maximum backoff between attempts, including waits requested by a
+	// Retry-After header. Defaults to 5s.
+	MaxBackoff time.Duration
+}
+
+// RetryMiddleware returns a Middleware that retries idempotent requests
+// (GET, HEAD, PUT, DELETE and OPTIONS) that fail with a transport error or
+// with a 429, 502, 503 or 504 status code, backing off exponentially between
+// attempts. A Retry-After header in seconds is honoured. Retries stop when the
+// request context is done.
+func RetryMiddleware(policy RetryPolicy) Middleware {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if policy.MinBackoff <= 0 {
+		policy.MinBackoff = defaultRetryMinBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = defaultRetryMaxBackoff
+	}
+
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if !isIdempotent(req.Method) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+				return next.Do(req)
+			}
+
+			backoff := policy.MinBackoff
+			for attempt := 1; ; attempt++ {
+				resp, err := next.Do(req)
+				if attempt == policy.MaxAttempts || req.Context().Err() != nil || !shouldRetry(resp, err) {
+					return resp, err
+				}
+
+				wait := backoff
+				if resp != nil {
+					if after, ok := retryAfter(resp); ok {
+						wait = after
+					}
+					io.Copy(ioutil.Discard, resp.Body)
+					resp.Body.Close()
+				}
+				if wait > policy.MaxBackoff {
+					wait = policy.MaxBackoff
+				}
+				if err := sleep(req.Context(), wait); err != nil {
+					return nil, err
+				}
+				backoff *= 2
+
+				if req.GetBody != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, err
+					}
+					req = req.Clone(req.Context())
+					req.Body = body
+				}
+			}
+		})
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the wait requested by a Retry-After header given in
+// seconds.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// sleep waits for d, returning early with the context's error if ctx is done
+// first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,101 @@
+package form3
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var testRetryPolicy = RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+func TestUnit_RetryMiddleware(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi(WithMiddleware(RetryMiddleware(testRetryPolicy)))
+	defer teardown()
+
+	attempts := 0
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"id": "1"}}`)
+	})
+
+	account, _, err := client.Accounts.Fetch(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if got, want := *account.Data.ID, "1"; got != want {
+		t.Errorf("Fetch returned ID %v, want %v", got, want)
+	}
+	if attempts != 3 {
+		t.Errorf("Server received %d attempts, want 3", attempts)
+	}
+}
+
+func TestUnit_RetryMiddleware_GivesUp(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi(WithMiddleware(RetryMiddleware(testRetryPolicy)))
+	defer teardown()
+
+	attempts := 0
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, resp, err := client.Accounts.Fetch(context.Background(), "1")
+	if err == nil {
+		t.Fatal("Fetch did not return error")
+	}
+	if got, want := resp.StatusCode, http.StatusServiceUnavailable; got != want {
+		t.Errorf("Fetch returned status %v, want %v", got, want)
+	}
+	if attempts != 3 {
+		t.Errorf("Server received %d attempts, want 3", attempts)
+	}
+}
+
+func TestUnit_RetryMiddleware_RewindsBody(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi(WithMiddleware(RetryMiddleware(testRetryPolicy)))
+	defer teardown()
+
+	var bodies []string
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req, _ := client.NewRequest("PUT", "organisation/accounts/1", map[string]string{"a": "b"})
+	if _, err := client.Do(context.Background(), req, nil); err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	if len(bodies) != 2 || bodies[0] != bodies[1] || bodies[1] == "" {
+		t.Errorf("Server received bodies %q, want the same body twice", bodies)
+	}
+}
+
+func TestUnit_RetryMiddleware_NotIdempotent(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi(WithMiddleware(RetryMiddleware(testRetryPolicy)))
+	defer teardown()
+
+	attempts := 0
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	client.Accounts.Create(context.Background(), &Account{})
+	if attempts != 1 {
+		t.Errorf("Server received %d attempts, want 1", attempts)
+	}
+}
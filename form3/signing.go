@@ -0,0 +1,132 @@
+package form3
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// A Signer signs requests for Credentials. Its key may be held locally, or
+// in a remote key management service.
+type Signer interface {
+	// Algorithm returns the name of the signature algorithm, as used in
+	// the algorithm parameter of an HTTP signature, e.g. "rsa-sha256".
+	Algorithm() string
+
+	// Sign returns the signature of data.
+	Sign(ctx context.Context, data []byte) ([]byte, error)
+}
+
+// rsaSigner signs with an RSA private key, using RSASSA-PKCS1-v1_5 with
+// SHA-256.
+type rsaSigner struct {
+	key *rsa.PrivateKey
+}
+
+// NewRSASigner returns a Signer signing with key, using RSASSA-PKCS1-v1_5
+// and SHA-256.
+func NewRSASigner(key *rsa.PrivateKey) Signer {
+	return &rsaSigner{key: key}
+}
+
+func (s *rsaSigner) Algorithm() string {
+	return "rsa-sha256"
+}
+
+func (s *rsaSigner) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	return rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+}
+
+// ParseRSAPrivateKey parses a PEM encoded RSA private key, in either PKCS #1
+// or PKCS #8 form.
+func ParseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM encoded key found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %v", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is a %T, not an RSA key", key)
+	}
+	return rsaKey, nil
+}
+
+// signRequest adds an HTTP signature to req, made by signer with the key
+// identified by keyID. The signature covers the request target, host and
+// date, and the digest of the body if there is one. It is sent in the
+// Authorization header, or in the Signature header if req already has an
+// Authorization header.
+//
+// Signature docs: https://tools.ietf.org/html/draft-cavage-http-signatures-10
+func signRequest(ctx context.Context, req *http.Request, keyID string, signer Signer) error {
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+
+	headers := []string{"(request-target)", "host", "date"}
+	if req.GetBody != nil && req.ContentLength != 0 {
+		body, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return err
+		}
+		digest := sha256.Sum256(data)
+		req.Header.Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(digest[:]))
+		headers = append(headers, "digest")
+	}
+
+	signature, err := signer.Sign(ctx, []byte(signingString(req, headers)))
+	if err != nil {
+		return err
+	}
+	value := fmt.Sprintf(`keyId="%s",algorithm="%s",headers="%s",signature="%s"`,
+		keyID, signer.Algorithm(), strings.Join(headers, " "), base64.StdEncoding.EncodeToString(signature))
+	if req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", "Signature "+value)
+	} else {
+		req.Header.Set("Signature", value)
+	}
+	return nil
+}
+
+// signingString returns the string signed for the given headers of req.
+func signingString(req *http.Request, headers []string) string {
+	lines := make([]string, len(headers))
+	for i, h := range headers {
+		var value string
+		switch h {
+		case "(request-target)":
+			value = strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			value = req.URL.Host
+			if req.Host != "" {
+				value = req.Host
+			}
+		default:
+			value = req.Header.Get(h)
+		}
+		lines[i] = h + ": " + value
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,240 @@
+package form3
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	testKeysOnce sync.Once
+	testKeys     [2]*rsa.PrivateKey
+)
+
+// testKey returns one of two RSA keys generated once for all tests.
+func testKey(t *testing.T, i int) *rsa.PrivateKey {
+	t.Helper()
+	testKeysOnce.Do(func() {
+		for i := range testKeys {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				panic(err)
+			}
+			testKeys[i] = key
+		}
+	})
+	return testKeys[i]
+}
+
+func pemEncode(key *rsa.PrivateKey) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+var signatureParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// verifySignature checks the HTTP signature of r against the public keys
+// by ID, returning the ID of the key that made it.
+func verifySignature(t *testing.T, r *http.Request, keys map[string]*rsa.PublicKey) string {
+	t.Helper()
+	value := r.Header.Get("Signature")
+	if value == "" {
+		value = strings.TrimPrefix(r.Header.Get("Authorization"), "Signature ")
+	}
+	params := make(map[string]string)
+	for _, m := range signatureParam.FindAllStringSubmatch(value, -1) {
+		params[m[1]] = m[2]
+	}
+
+	var lines []string
+	for _, h := range strings.Fields(params["headers"]) {
+		switch h {
+		case "(request-target)":
+			lines = append(lines, h+": "+strings.ToLower(r.Method)+" "+r.RequestURI)
+		case "host":
+			lines = append(lines, h+": "+r.Host)
+		default:
+			lines = append(lines, h+": "+r.Header.Get(h))
+		}
+	}
+	if r.Header.Get("Digest") != "" {
+		body, _ := ioutil.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		if want := "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:]); r.Header.Get("Digest") != want {
+			t.Errorf("Digest = %v, want %v", r.Header.Get("Digest"), want)
+		}
+	}
+
+	key := keys[params["keyId"]]
+	if key == nil {
+		t.Errorf("Request signed with unknown key %q", params["keyId"])
+		return ""
+	}
+	signature, _ := base64.StdEncoding.DecodeString(params["signature"])
+	digest := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("Signature by %s does not verify: %v", params["keyId"], err)
+	}
+	if params["algorithm"] != "rsa-sha256" {
+		t.Errorf("Signature algorithm = %v, want rsa-sha256", params["algorithm"])
+	}
+	return params["keyId"]
+}
+
+func TestUnit_Client_SignedRequests(t *testing.T) {
+	key := testKey(t, 0)
+	client, mux, _, teardown := setupClientWithStubbedApi(WithCredentials(Credentials{SigningKeyID: "key-1", Signer: NewRSASigner(key)}))
+	defer teardown()
+
+	keys := map[string]*rsa.PublicKey{"key-1": &key.PublicKey}
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Signature ") {
+			t.Errorf("Authorization = %q, want a signature", r.Header.Get("Authorization"))
+		}
+		if !strings.Contains(r.Header.Get("Authorization"), `headers="(request-target) host date digest"`) {
+			t.Errorf("Signature does not cover the body digest: %v", r.Header.Get("Authorization"))
+		}
+		verifySignature(t, r, keys)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		verifySignature(t, r, keys)
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+
+	if _, _, err := client.Accounts.Create(context.Background(), &Account{ID: String("1")}); err != nil {
+		t.Fatalf("Accounts.Create returned error: %v", err)
+	}
+	if _, _, err := client.Accounts.Fetch(context.Background(), "1"); err != nil {
+		t.Fatalf("Accounts.Fetch returned error: %v", err)
+	}
+}
+
+func TestUnit_Client_SignedRequests_WithAccessToken(t *testing.T) {
+	key := testKey(t, 0)
+	client, mux, _, teardown := setupClientWithStubbedApi(WithCredentials(Credentials{
+		ClientID: "client", ClientSecret: "secret", SigningKeyID: "key-1", Signer: NewRSASigner(key),
+	}))
+	defer teardown()
+
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Signature") != "" {
+			t.Errorf("Token request was signed")
+		}
+		fmt.Fprint(w, `{"access_token": "token", "token_type": "bearer", "expires_in": 3600}`)
+	})
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "Authorization", "Bearer token")
+		verifySignature(t, r, map[string]*rsa.PublicKey{"key-1": &key.PublicKey})
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+
+	if _, _, err := client.Accounts.Fetch(context.Background(), "1"); err != nil {
+		t.Fatalf("Accounts.Fetch returned error: %v", err)
+	}
+}
+
+func TestUnit_ParseRSAPrivateKey(t *testing.T) {
+	key := testKey(t, 0)
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(key)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecPKCS8, _ := x509.MarshalPKCS8PrivateKey(ecKey)
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"PKCS #1", pemEncode(key), false},
+		{"PKCS #8", string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})), false},
+		{"not PEM", "not a key", true},
+		{"not RSA", string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecPKCS8})), true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRSAPrivateKey([]byte(tt.data))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRSAPrivateKey(%s) returned error %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil && !got.Equal(key) {
+			t.Errorf("ParseRSAPrivateKey(%s) returned a different key", tt.name)
+		}
+	}
+}
+
+// countingCredentials is a CredentialsProvider counting the times it is
+// asked for credentials.
+type countingCredentials struct {
+	credentials *Credentials
+	calls       int32
+}
+
+func (c *countingCredentials) Credentials(ctx context.Context) (*Credentials, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return c.credentials, nil
+}
+
+func TestUnit_Client_SignedRequests_Retried(t *testing.T) {
+	key := testKey(t, 0)
+	provider := &countingCredentials{credentials: &Credentials{
+		ClientID: "client", ClientSecret: "secret", SigningKeyID: "key-1", Signer: NewRSASigner(key),
+	}}
+	var seen []string
+	record := func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			seen = append(seen, req.Header.Get("Authorization")+req.Header.Get("Signature"))
+			return next.Do(req)
+		})
+	}
+	client, mux, _, teardown := setupClientWithStubbedApi(
+		WithCredentialsProvider(provider),
+		WithMiddleware(RetryMiddleware(RetryPolicy{MinBackoff: time.Millisecond}), record),
+	)
+	defer teardown()
+
+	// Tokens expire within the expiry margin, so each attempt needs a new one.
+	tokens := 0
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		tokens++
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "bearer", "expires_in": 1}`, tokens)
+	})
+	var authorizations []string
+	mux.HandleFunc("/organisation/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		verifySignature(t, r, map[string]*rsa.PublicKey{"key-1": &key.PublicKey})
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		if len(authorizations) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+
+	if _, _, err := client.Accounts.Fetch(context.Background(), "1"); err != nil {
+		t.Fatalf("Accounts.Fetch returned error: %v", err)
+	}
+	if want := []string{"Bearer token-1", "Bearer token-2"}; !reflect.DeepEqual(authorizations, want) {
+		t.Errorf("Attempts authorized with %v, want %v", authorizations, want)
+	}
+	if want := []string{"", ""}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("Middleware saw authorization %q, want none", seen)
+	}
+	if provider.calls != 1 {
+		t.Errorf("Asked for credentials %d times, want once per call", provider.calls)
+	}
+}
@@ -0,0 +1,151 @@
+package form3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// A bodyDecoder decodes a response body itself, instead of Client.Do
+// decoding the whole body at once. It is only called for successful
+// responses.
+type bodyDecoder interface {
+	decodeBody(r io.Reader) error
+}
+
+// accountStream decodes a list of accounts one at a time, passing each to
+// fn and recording the links of the list.
+type accountStream struct {
+	fn    func(*Account) error
+	links *Links
+}
+
+func (s *accountStream) decodeBody(r io.Reader) error {
+	links, err := decodeList(r, func(dec *json.Decoder) error {
+		account := new(Account)
+		if err := dec.Decode(account); err != nil {
+			return err
+		}
+		return s.fn(account)
+	})
+	s.links = links
+	return err
+}
+
+// decodeList decodes a JSON:API list document from r, calling decodeElem
+// to decode each element of its data array in turn, and returns its links.
+// Other members of the document are skipped.
+func decodeList(r io.Reader, decodeElem func(dec *json.Decoder) error) (*Links, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		if err == io.EOF {
+			err = nil // ignore EOF errors caused by empty response body
+		}
+		return nil, err
+	}
+
+	var links *Links
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tok {
+		case "data":
+			if err := decodeArray(dec, decodeElem); err != nil {
+				return nil, err
+			}
+		case "links":
+			if err := dec.Decode(&links); err != nil {
+				return nil, err
+			}
+		default:
+			if err := skipValue(dec); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return links, expectDelim(dec, '}')
+}
+
+// decodeArray decodes a JSON array, or null, calling decodeElem for each
+// element.
+func decodeArray(dec *json.Decoder, decodeElem func(dec *json.Decoder) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('[') {
+		return fmt.Errorf("expected array, found %v", tok)
+	}
+	for dec.More() {
+		if err := decodeElem(dec); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+// skipValue reads past the next JSON value without decoding it.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return fmt.Errorf("expected %v, found %v", want, tok)
+	}
+	return nil
+}
+
+// ListStream lists accounts like ListWithOptions, but decodes the response
+// one account at a time as it is read, calling fn for each, so that a page
+// of accounts is never held in memory at once. It returns the links of the
+// page for pagination. Relations are not resolved, as the included
+// resources may follow the accounts.
+//
+// If fn returns an error, decoding stops and the error is returned. If the
+// response cannot be decoded, fn may already have been called for the
+// accounts before the error.
+func (s *AccountsService) ListStream(ctx context.Context, options *AccountListOptions, fn func(*Account) error) (*Links, *Response, error) {
+	u, err := addOptions("organisation/accounts", s.scopeListOptions(options))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stream := &accountStream{fn: fn}
+	resp, err := s.client.Do(withOperation(ctx, "accounts.list"), req, stream)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return stream.links, resp, nil
+}
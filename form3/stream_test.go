@@ -0,0 +1,166 @@
+package form3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnit_AccountsService_ListStream(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testFormValues(t, r, values{"page[size]": "2"})
+		fmt.Fprint(w, `
+		{
+			"meta": {"count": 2, "nested": [{"a": [1, 2]}]},
+			"data": [
+				{"id": "1", "type": "accounts", "attributes": {"country": "GB"}},
+				{"id": "2", "type": "accounts", "attributes": {"country": "FR"}}
+			],
+			"included": [{"id": "3", "type": "accounts"}],
+			"links": {"self": "/v1/organisation/accounts?page[size]=2", "next": "/v1/organisation/accounts?page[number]=1&page[size]=2"}
+		}`)
+	})
+
+	var ids []string
+	links, _, err := client.Accounts.ListStream(context.Background(), &AccountListOptions{ListOptions: ListOptions{PageSize: 2}}, func(a *Account) error {
+		ids = append(ids, *a.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Accounts.ListStream returned error: %v", err)
+	}
+	if want := []string{"1", "2"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Accounts.ListStream passed %v, want %v", ids, want)
+	}
+	if links == nil || links.Next == nil || *links.Next != "/v1/organisation/accounts?page[number]=1&page[size]=2" {
+		t.Errorf("Accounts.ListStream returned links %+v, want next page", links)
+	}
+}
+
+func TestUnit_AccountsService_ListStream_CallbackError(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [{"id": "1"}, {"id": "2"}]}`)
+	})
+
+	stop := errors.New("stop")
+	calls := 0
+	_, _, err := client.Accounts.ListStream(context.Background(), nil, func(a *Account) error {
+		calls++
+		return stop
+	})
+	if err != stop {
+		t.Errorf("Accounts.ListStream returned error %v, want %v", err, stop)
+	}
+	if calls != 1 {
+		t.Errorf("Callback called %d times, want 1", calls)
+	}
+}
+
+func TestUnit_AccountsService_ListStream_APIError(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+
+	mux.HandleFunc("/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error_message": "invalid filter"}`)
+	})
+
+	_, _, err := client.Accounts.ListStream(context.Background(), nil, func(a *Account) error {
+		t.Errorf("Callback called for an error response")
+		return nil
+	})
+	if err, ok := err.(*ErrorResponse); !ok || err.Message != "invalid filter" {
+		t.Errorf("Accounts.ListStream returned error %v, want *ErrorResponse", err)
+	}
+}
+
+func TestUnit_decodeList(t *testing.T) {
+	tests := []struct {
+		body    string
+		want    int
+		wantErr bool
+	}{
+		{``, 0, false},
+		{`{}`, 0, false},
+		{`{"data": null}`, 0, false},
+		{`{"data": []}`, 0, false},
+		{`{"data": [{}, {}, {}]}`, 3, false},
+		{`{"data": {}}`, 0, true},
+		{`{"data": [{}, `, 2, true},
+		{`[]`, 0, true},
+	}
+	for _, tt := range tests {
+		n := 0
+		_, err := decodeList(strings.NewReader(tt.body), func(dec *json.Decoder) error {
+			n++
+			return dec.Decode(new(Account))
+		})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("decodeList(%q) returned error %v, want error %v", tt.body, err, tt.wantErr)
+		}
+		if n != tt.want {
+			t.Errorf("decodeList(%q) decoded %d elements, want %d", tt.body, n, tt.want)
+		}
+	}
+}
+
+// benchmarkListBody returns a list response body with n accounts.
+func benchmarkListBody(n int) []byte {
+	list := &AccountDetailsListResponse{Links: &Links{Self: String("/v1/organisation/accounts")}}
+	for i := 0; i < n; i++ {
+		list.Data = append(list.Data, &Account{
+			Type:           String("accounts"),
+			ID:             String(fmt.Sprintf("ad27e265-9605-4b4b-a0e5-%012d", i)),
+			OrganisationId: String("eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"),
+			Version:        Int(0),
+			Attributes: &AccountAttributes{
+				Country:       CountryGB.Ptr(),
+				BaseCurrency:  CurrencyGBP.Ptr(),
+				BankId:        String("400300"),
+				BankIdCode:    BankIdCodeGBDSC.Ptr(),
+				BIC:           String("NWBKGB22"),
+				AccountNumber: String(fmt.Sprintf("%08d", i)),
+				Name:          []string{"Samantha Holder"},
+			},
+		})
+	}
+	data, _ := json.Marshal(list)
+	return data
+}
+
+func BenchmarkDecodeList(b *testing.B) {
+	body := benchmarkListBody(1000)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for i := 0; i < b.N; i++ {
+		list := new(AccountDetailsListResponse)
+		if err := json.NewDecoder(bytes.NewReader(body)).Decode(list); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeListStream(b *testing.B) {
+	body := benchmarkListBody(1000)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for i := 0; i < b.N; i++ {
+		stream := &accountStream{fn: func(*Account) error { return nil }}
+		if err := stream.decodeBody(bytes.NewReader(body)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
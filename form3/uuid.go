@@ -0,0 +1,24 @@
+package form3
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// An IDGenerator returns a new identifier for a resource that is about to be
+// created.
+type IDGenerator func() (string, error)
+
+// NewUUID returns a random (version 4) UUID in its canonical string form, as
+// described in RFC 4122. It is the default IDGenerator of a Client.
+func NewUUID() (string, error) {
+	var u [16]byte
+	if _, err := io.ReadFull(rand.Reader, u[:]); err != nil {
+		return "", err
+	}
+	u[6] = (u[6] & 0x0f) | 0x40 // version 4
+	u[8] = (u[8] & 0x3f) | 0x80 // variant 10
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16]), nil
+}
@@ -0,0 +1,25 @@
+package form3
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestUnit_NewUUID(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id, err := NewUUID()
+		if err != nil {
+			t.Fatalf("NewUUID returned error: %v", err)
+		}
+		if !pattern.MatchString(id) {
+			t.Errorf("NewUUID returned %q, want a version 4 UUID", id)
+		}
+		if seen[id] {
+			t.Errorf("NewUUID returned duplicate %q", id)
+		}
+		seen[id] = true
+	}
+}
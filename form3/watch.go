@@ -0,0 +1,324 @@
+package form3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// defaultWatchInterval is the time between polls of an AccountWatcher.
+const defaultWatchInterval = 30 * time.Second
+
+// AccountEventType is the kind of change reported by an AccountEvent.
+type AccountEventType string
+
+// Account event types.
+const (
+	AccountCreated AccountEventType = "created"
+	AccountUpdated AccountEventType = "updated"
+	AccountDeleted AccountEventType = "deleted"
+)
+
+// An AccountEvent reports a change to an account seen by an AccountWatcher.
+type AccountEvent struct {
+	Type AccountEventType
+
+	// The account as listed. For deleted accounts only the ID and the last
+	// version seen are set.
+	Account *Account
+
+	// Version of the account when last seen, for updated and deleted
+	// accounts.
+	PreviousVersion int
+}
+
+// WatchState is what an AccountWatcher has seen: the version of each
+// account at the last poll.
+type WatchState struct {
+	Versions map[string]int `json:"versions"`
+	PolledAt time.Time      `json:"polled_at"`
+}
+
+// A WatchStore persists the state of an AccountWatcher, so that it can
+// resume after a restart without missing or repeating changes.
+type WatchStore interface {
+	// Load returns the state last saved, or nil if there is none.
+	Load() (*WatchState, error)
+
+	// Save records state.
+	Save(state *WatchState) error
+}
+
+// FileWatchStore is a WatchStore keeping the state in a JSON file.
+type FileWatchStore struct {
+	Path string
+}
+
+// Load reads the state from the file, returning nil if it does not exist.
+func (s *FileWatchStore) Load() (*WatchState, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := new(WatchState)
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("reading watch state from %s: %v", s.Path, err)
+	}
+	return state, nil
+}
+
+// Save writes the state to a temporary file and renames it over the file, so
+// that the file always holds a complete state.
+func (s *FileWatchStore) Save(state *WatchState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Path)
+}
+
+// WatchOptions specifies the optional parameters to AccountsService.Watcher.
+type WatchOptions struct {
+	// Time between polls. Defaults to 30 seconds.
+	Interval time.Duration
+
+	// Watches only the accounts matching the filter. Accounts that stop
+	// matching it are reported as deleted.
+	Filter *AccountFilter
+
+	// Number of accounts requested per page. Defaults to 100.
+	PageSize int
+
+	// Persists the state of the watcher. If nil, the state is kept in
+	// memory only.
+	Store WatchStore
+
+	// If true and there is no saved state, the first poll reports every
+	// account as created. Otherwise it records the accounts without
+	// reporting them.
+	EmitExisting bool
+}
+
+// An AccountWatcher reports changes to accounts by polling the list of
+// accounts and comparing the ID and version of each with the last poll.
+// Create one with AccountsService.Watcher.
+type AccountWatcher struct {
+	s     *AccountsService
+	opts  WatchOptions
+	state *WatchState
+}
+
+// Watcher returns an AccountWatcher for the accounts of s.
+func (s *AccountsService) Watcher(opts *WatchOptions) *AccountWatcher {
+	w := &AccountWatcher{s: s}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.Interval <= 0 {
+		w.opts.Interval = defaultWatchInterval
+	}
+	return w
+}
+
+// Run polls for changes every interval until ctx is done or fn returns an
+// error, calling fn for each change. The state is saved after fn has been
+// called for every change found by a poll, so changes are reported again
+// after a restart if fn fails or the process stops part way through.
+func (w *AccountWatcher) Run(ctx context.Context, fn func(*AccountEvent) error) error {
+	for {
+		events, next, err := w.poll(ctx)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		if err := w.commit(next); err != nil {
+			return err
+		}
+		if err := sleep(ctx, w.opts.Interval); err != nil {
+			return err
+		}
+	}
+}
+
+// Poll lists the accounts once, saves the state and returns the changes
+// since the last poll.
+func (w *AccountWatcher) Poll(ctx context.Context) ([]*AccountEvent, error) {
+	events, next, err := w.poll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return events, w.commit(next)
+}
+
+// poll lists the accounts and returns the changes since the last poll and
+// the state to save once they have been handled.
+func (w *AccountWatcher) poll(ctx context.Context) ([]*AccountEvent, *WatchState, error) {
+	previous, err := w.load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Accounts created or deleted while paging shift the pages, so an
+	// account may be listed twice or not at all. Duplicates are merged
+	// here, and accounts missing from the list are checked below.
+	listed := make(map[string]*Account)
+	opts := &AccountListOptions{ListOptions: ListOptions{PageSize: w.opts.PageSize}, Filter: w.opts.Filter}
+	err = w.s.ListAll(ctx, opts, func(a *Account) error {
+		if a.ID == nil {
+			return nil
+		}
+		if seen, ok := listed[*a.ID]; !ok || version(a) > version(seen) {
+			listed[*a.ID] = a
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	next := &WatchState{Versions: make(map[string]int, len(listed)), PolledAt: time.Now().UTC()}
+	for id, a := range listed {
+		next.Versions[id] = version(a)
+	}
+
+	var events []*AccountEvent
+	if previous == nil {
+		if w.opts.EmitExisting {
+			for _, id := range sortedIDs(listed) {
+				events = append(events, &AccountEvent{Type: AccountCreated, Account: listed[id]})
+			}
+		}
+		return events, next, nil
+	}
+
+	for _, id := range sortedIDs(listed) {
+		a := listed[id]
+		before, ok := previous.Versions[id]
+		switch {
+		case !ok:
+			events = append(events, &AccountEvent{Type: AccountCreated, Account: a})
+		case version(a) != before:
+			events = append(events, &AccountEvent{Type: AccountUpdated, Account: a, PreviousVersion: before})
+		}
+	}
+
+	var missing []string
+	for id := range previous.Versions {
+		if _, ok := listed[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	sort.Strings(missing)
+	for _, id := range missing {
+		before := previous.Versions[id]
+		details, _, err := w.s.Fetch(ctx, id)
+		switch {
+		case isStatus(err, http.StatusNotFound):
+			events = append(events, &AccountEvent{Type: AccountDeleted, Account: &Account{ID: String(id), Version: Int(before)}, PreviousVersion: before})
+		case err != nil:
+			return nil, nil, err
+		default:
+			// The account was missed by paging, or no longer matches the
+			// filter but still exists.
+			a := details.Data
+			if w.opts.Filter != nil && !w.opts.Filter.matches(a) {
+				events = append(events, &AccountEvent{Type: AccountDeleted, Account: &Account{ID: String(id), Version: Int(before)}, PreviousVersion: before})
+				continue
+			}
+			next.Versions[id] = version(a)
+			if version(a) != before {
+				events = append(events, &AccountEvent{Type: AccountUpdated, Account: a, PreviousVersion: before})
+			}
+		}
+	}
+	return events, next, nil
+}
+
+// matches reports whether a has every attribute set in f.
+func (f *AccountFilter) matches(a *Account) bool {
+	attrs := a.Attributes
+	if attrs == nil {
+		attrs = new(AccountAttributes)
+	}
+	bankIdCode, country := BankIdCode(""), Country("")
+	if attrs.BankIdCode != nil {
+		bankIdCode = *attrs.BankIdCode
+	}
+	if attrs.Country != nil {
+		country = *attrs.Country
+	}
+	return (f.OrganisationID == "" || f.OrganisationID == stringValue(a.OrganisationId)) &&
+		(f.BankIdCode == "" || f.BankIdCode == bankIdCode) &&
+		(f.BankID == "" || f.BankID == stringValue(attrs.BankId)) &&
+		(f.AccountNumber == "" || f.AccountNumber == stringValue(attrs.AccountNumber)) &&
+		(f.IBAN == "" || normaliseIBAN(f.IBAN) == normaliseIBAN(stringValue(attrs.IBAN))) &&
+		(f.CustomerID == "" || f.CustomerID == stringValue(attrs.CustomerId)) &&
+		(f.Country == "" || f.Country == country)
+}
+
+// load returns the state of the last poll, loading it from the store the
+// first time.
+func (w *AccountWatcher) load() (*WatchState, error) {
+	if w.state == nil && w.opts.Store != nil {
+		state, err := w.opts.Store.Load()
+		if err != nil {
+			return nil, err
+		}
+		w.state = state
+	}
+	return w.state, nil
+}
+
+// commit records state as the state of the last poll.
+func (w *AccountWatcher) commit(state *WatchState) error {
+	if w.opts.Store != nil {
+		if err := w.opts.Store.Save(state); err != nil {
+			return err
+		}
+	}
+	w.state = state
+	return nil
+}
+
+// version returns the version of a, treating a missing version as 0.
+func version(a *Account) int {
+	if a.Version == nil {
+		return 0
+	}
+	return *a.Version
+}
+
+func sortedIDs(accounts map[string]*Account) []string {
+	ids := make([]string, 0, len(accounts))
+	for id := range accounts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,246 @@
+package form3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// watchedAccounts serves a mutable set of accounts from the list and fetch
+// endpoints. IDs in hidden are left out of lists, as if paging had shifted.
+type watchedAccounts struct {
+	mu       sync.Mutex
+	accounts map[string]*Account
+	hidden   map[string]bool
+}
+
+func serveWatchedAccounts(mux *http.ServeMux, ids ...string) *watchedAccounts {
+	w := &watchedAccounts{accounts: make(map[string]*Account), hidden: make(map[string]bool)}
+	for _, id := range ids {
+		w.set(id, 0)
+	}
+	mux.HandleFunc("/organisation/accounts", func(rw http.ResponseWriter, r *http.Request) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		var ids []string
+		for id := range w.accounts {
+			if !w.hidden[id] {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+		number, _ := strconv.Atoi(r.URL.Query().Get("page[number]"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("page[size]"))
+		var page []*Account
+		for i := number * size; i < len(ids) && i < (number+1)*size; i++ {
+			page = append(page, w.accounts[ids[i]])
+		}
+		json.NewEncoder(rw).Encode(&AccountDetailsListResponse{Data: page})
+	})
+	mux.HandleFunc("/organisation/accounts/", func(rw http.ResponseWriter, r *http.Request) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		a, ok := w.accounts[strings.TrimPrefix(r.URL.Path, "/organisation/accounts/")]
+		if !ok {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(rw).Encode(&AccountDetailsResponse{Data: a})
+	})
+	return w
+}
+
+func (w *watchedAccounts) set(id string, version int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.accounts[id] = &Account{ID: String(id), Version: Int(version), Attributes: &AccountAttributes{Country: CountryGB.Ptr()}}
+}
+
+func (w *watchedAccounts) remove(id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.accounts, id)
+}
+
+// describeEvents returns a comparable description of events.
+func describeEvents(events []*AccountEvent) []string {
+	var s []string
+	for _, e := range events {
+		s = append(s, string(e.Type)+" "+*e.Account.ID+" v"+strconv.Itoa(*e.Account.Version))
+	}
+	return s
+}
+
+func TestUnit_AccountWatcher_Poll(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	accounts := serveWatchedAccounts(mux, "a", "b", "c")
+	watcher := client.Accounts.Watcher(&WatchOptions{PageSize: 2})
+
+	events, err := watcher.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("First poll returned %v, want no events", describeEvents(events))
+	}
+
+	accounts.set("b", 1)
+	accounts.remove("c")
+	accounts.set("d", 0)
+
+	events, err = watcher.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	want := []string{"updated b v1", "created d v0", "deleted c v0"}
+	if got := describeEvents(events); !reflect.DeepEqual(got, want) {
+		t.Errorf("Poll returned %v, want %v", got, want)
+	}
+	if events[0].PreviousVersion != 0 {
+		t.Errorf("Updated event previous version = %d, want 0", events[0].PreviousVersion)
+	}
+
+	events, err = watcher.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Poll without changes returned %v, want no events", describeEvents(events))
+	}
+}
+
+func TestUnit_AccountWatcher_EmitExisting(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	serveWatchedAccounts(mux, "a", "b")
+
+	events, err := client.Accounts.Watcher(&WatchOptions{EmitExisting: true}).Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if got, want := describeEvents(events), []string{"created a v0", "created b v0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Poll returned %v, want %v", got, want)
+	}
+}
+
+func TestUnit_AccountWatcher_MissedByPaging(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	accounts := serveWatchedAccounts(mux, "a", "b")
+	watcher := client.Accounts.Watcher(nil)
+	if _, err := watcher.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts.hidden["b"] = true
+	accounts.set("b", 2)
+
+	events, err := watcher.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if got, want := describeEvents(events), []string{"updated b v2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Poll returned %v, want %v", got, want)
+	}
+}
+
+func TestUnit_AccountWatcher_FilterNoLongerMatches(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	accounts := serveWatchedAccounts(mux, "a")
+	watcher := client.Accounts.Watcher(&WatchOptions{Filter: &AccountFilter{Country: CountryGB}})
+	if _, err := watcher.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts.hidden["a"] = true
+	accounts.accounts["a"].Attributes.Country = CountryFR.Ptr()
+
+	events, err := watcher.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if got, want := describeEvents(events), []string{"deleted a v0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Poll returned %v, want %v", got, want)
+	}
+}
+
+func TestUnit_AccountWatcher_Resume(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	accounts := serveWatchedAccounts(mux, "a")
+	store := &FileWatchStore{Path: filepath.Join(t.TempDir(), "watch.json")}
+
+	if _, err := client.Accounts.Watcher(&WatchOptions{Store: store}).Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	accounts.set("a", 1)
+
+	events, err := client.Accounts.Watcher(&WatchOptions{Store: store}).Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if got, want := describeEvents(events), []string{"updated a v1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Poll after restart returned %v, want %v", got, want)
+	}
+
+	state, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := state.Versions["a"]; got != 1 {
+		t.Errorf("Saved version of a = %d, want 1", got)
+	}
+}
+
+func TestUnit_FileWatchStore_Missing(t *testing.T) {
+	store := &FileWatchStore{Path: filepath.Join(t.TempDir(), "watch.json")}
+
+	state, err := store.Load()
+	if err != nil || state != nil {
+		t.Errorf("Load = %v, %v, want nil, nil", state, err)
+	}
+}
+
+func TestUnit_AccountWatcher_Run(t *testing.T) {
+	client, mux, _, teardown := setupClientWithStubbedApi()
+	defer teardown()
+	accounts := serveWatchedAccounts(mux, "a")
+	watcher := client.Accounts.Watcher(&WatchOptions{Interval: time.Millisecond})
+	if _, err := watcher.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	accounts.set("b", 0)
+
+	failed := errors.New("handler failed")
+	err := watcher.Run(context.Background(), func(e *AccountEvent) error {
+		return failed
+	})
+	if err != failed {
+		t.Fatalf("Run returned error %v, want %v", err, failed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var got []string
+	err = watcher.Run(ctx, func(e *AccountEvent) error {
+		got = append(got, describeEvents([]*AccountEvent{e})...)
+		cancel()
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("Run returned error %v, want %v", err, context.Canceled)
+	}
+	if want := []string{"created b v0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Run redelivered %v, want %v", got, want)
+	}
+}
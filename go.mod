@@ -1,5 +1,5 @@
 module form3.tech/go-form3
 
-go 1.15
+go 1.21
 
 require github.com/google/go-querystring v1.0.0
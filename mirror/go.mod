@@ -0,0 +1,15 @@
+module form3.tech/go-form3/mirror
+
+go 1.25.0
+
+require (
+	form3.tech/go-form3 v0.0.0-00010101000000-000000000000
+	go.etcd.io/bbolt v1.5.0
+)
+
+require (
+	github.com/google/go-querystring v1.0.0 // indirect
+	golang.org/x/sys v0.45.0 // indirect
+)
+
+replace form3.tech/go-form3 => ../
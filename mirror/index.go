@@ -0,0 +1,114 @@
+package mirror
+
+import (
+	"bytes"
+	"encoding/json"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Index buckets map the value of an attribute to the IDs of the accounts
+// with it. Each entry is a key holding the value and the ID, separated by a
+// zero byte, so that the IDs with a value are found by a prefix scan in ID
+// order.
+var (
+	bankIDIndex = []byte("by_bank_id")
+	statusIndex = []byte("by_status")
+)
+
+// indexed holds the attributes of an account that are indexed.
+type indexed struct {
+	Attributes struct {
+		BankID string `json:"bank_id"`
+		Status string `json:"status"`
+	} `json:"attributes"`
+}
+
+// decodeIndexed decodes the indexed attributes of a stored account, or
+// returns nil if data is nil.
+func decodeIndexed(data []byte) (*indexed, error) {
+	if data == nil {
+		return nil, nil
+	}
+	ix := new(indexed)
+	if err := json.Unmarshal(data, ix); err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+// entries returns the keys of the index entries of the account with the
+// given ID, by index bucket. Unset attributes are not indexed.
+func (ix *indexed) entries(id string) map[string][]byte {
+	entries := make(map[string][]byte)
+	if bankID := normaliseSortCode(ix.Attributes.BankID); bankID != "" {
+		entries[string(bankIDIndex)] = indexKey(bankID, id)
+	}
+	if ix.Attributes.Status != "" {
+		entries[string(statusIndex)] = indexKey(ix.Attributes.Status, id)
+	}
+	return entries
+}
+
+func indexKey(value, id string) []byte {
+	return []byte(value + "\x00" + id)
+}
+
+// reindex replaces the index entries of the account with the given ID for
+// its attributes before, which may be nil if it was not stored, with those
+// for its attributes after, which may be nil if it has been deleted.
+func reindex(tx *bolt.Tx, id string, before, after *indexed) error {
+	if before != nil {
+		for name, key := range before.entries(id) {
+			if err := tx.Bucket([]byte(name)).Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+	if after != nil {
+		for name, key := range after.entries(id) {
+			if err := tx.Bucket([]byte(name)).Put(key, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// createIndexes creates the index buckets if they do not exist, indexing
+// the accounts already stored, such as those of a mirror written before the
+// indexes were added.
+func createIndexes(tx *bolt.Tx) error {
+	if tx.Bucket(bankIDIndex) != nil && tx.Bucket(statusIndex) != nil {
+		return nil
+	}
+	for _, name := range [][]byte{bankIDIndex, statusIndex} {
+		if tx.Bucket(name) != nil {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.CreateBucket(name); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(accountsBucket).ForEach(func(k, v []byte) error {
+		ix, err := decodeIndexed(v)
+		if err != nil {
+			return err
+		}
+		return reindex(tx, string(k), nil, ix)
+	})
+}
+
+// lookup returns the IDs of the accounts with the given value in the index
+// bucket name, in ID order.
+func lookup(tx *bolt.Tx, name []byte, value string) [][]byte {
+	prefix := []byte(value + "\x00")
+	var ids [][]byte
+	c := tx.Bucket(name).Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		ids = append(ids, append([]byte(nil), k[len(prefix):]...))
+	}
+	return ids
+}
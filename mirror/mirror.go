@@ -0,0 +1,260 @@
+// Package mirror keeps a local copy of an organisation's accounts in an
+// embedded key-value store, for fast offline queries that do not load the
+// API.
+//
+//	m, err := mirror.Open("accounts.db", client.Accounts, nil)
+//	defer m.Close()
+//	result, err := m.Refresh(ctx)
+//	accounts, err := m.Query(&mirror.Query{SortCode: "40-03-00", Status: form3.StatusConfirmed})
+//
+// Refreshing lists every account and writes only those whose version has
+// changed since the last refresh, removing those that have been deleted.
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"form3.tech/go-form3/form3"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	accountsBucket = []byte("accounts")
+	metaBucket     = []byte("meta")
+	syncedAtKey    = []byte("synced_at")
+)
+
+// ErrNotFound is returned by Get when the mirror has no account with the
+// given ID.
+var ErrNotFound = errors.New("account not found in mirror")
+
+// Options specifies the optional parameters to Open.
+type Options struct {
+	// Mirrors only the accounts matching the filter.
+	Filter *form3.AccountFilter
+
+	// Number of accounts requested per page when refreshing. Defaults to
+	// 100.
+	PageSize int
+}
+
+// A Mirror is a local copy of the accounts listed by an AccountsService.
+// It is safe for concurrent use.
+type Mirror struct {
+	db       *bolt.DB
+	accounts *form3.AccountsService
+	opts     Options
+}
+
+// Open opens the mirror stored in the file at path, creating it if it does
+// not exist. The mirror is empty until it is first refreshed.
+func Open(path string, accounts *form3.AccountsService, opts *Options) (*Mirror, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(accountsBucket); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists(metaBucket); err != nil {
+			return err
+		}
+		return createIndexes(tx)
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	m := &Mirror{db: db, accounts: accounts}
+	if opts != nil {
+		m.opts = *opts
+	}
+	return m, nil
+}
+
+// Close closes the store.
+func (m *Mirror) Close() error {
+	return m.db.Close()
+}
+
+// A RefreshResult counts the changes written by Refresh.
+type RefreshResult struct {
+	Created int
+	Updated int
+	Deleted int
+}
+
+// Refresh brings the mirror up to date, writing the accounts created,
+// updated or deleted since the last refresh. The changes, the indexes and
+// the refresh time are written together, so a refresh that fails leaves the
+// mirror as it was.
+func (m *Mirror) Refresh(ctx context.Context) (*RefreshResult, error) {
+	// A new watcher loads the versions from the store each time, so that
+	// changes are recomputed if a previous refresh failed.
+	watcher := m.accounts.Watcher(&form3.WatchOptions{
+		Filter:       m.opts.Filter,
+		PageSize:     m.opts.PageSize,
+		Store:        versionStore{m},
+		EmitExisting: true,
+	})
+	events, err := watcher.Poll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(RefreshResult)
+	err = m.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(accountsBucket)
+		for _, e := range events {
+			id := []byte(*e.Account.ID)
+			before, err := decodeIndexed(b.Get(id))
+			if err != nil {
+				return err
+			}
+			if e.Type == form3.AccountDeleted {
+				result.Deleted++
+				if err := b.Delete(id); err != nil {
+					return err
+				}
+				if err := reindex(tx, *e.Account.ID, before, nil); err != nil {
+					return err
+				}
+				continue
+			}
+			if e.Type == form3.AccountCreated {
+				result.Created++
+			} else {
+				result.Updated++
+			}
+			data, err := json.Marshal(e.Account)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(id, data); err != nil {
+				return err
+			}
+			after, err := decodeIndexed(data)
+			if err != nil {
+				return err
+			}
+			if err := reindex(tx, *e.Account.ID, before, after); err != nil {
+				return err
+			}
+		}
+		syncedAt, err := time.Now().UTC().MarshalText()
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(metaBucket).Put(syncedAtKey, syncedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SyncedAt returns the time of the last successful refresh, or the zero
+// time if the mirror has never been refreshed.
+func (m *Mirror) SyncedAt() (time.Time, error) {
+	var t time.Time
+	err := m.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(metaBucket).Get(syncedAtKey)
+		if data == nil {
+			return nil
+		}
+		return t.UnmarshalText(data)
+	})
+	return t, err
+}
+
+// Staleness returns how long ago the mirror was last refreshed. A mirror
+// that has never been refreshed is infinitely stale, and its staleness is
+// the maximum duration.
+func (m *Mirror) Staleness() (time.Duration, error) {
+	syncedAt, err := m.SyncedAt()
+	if err != nil {
+		return 0, err
+	}
+	if syncedAt.IsZero() {
+		return time.Duration(1<<63 - 1), nil
+	}
+	return time.Since(syncedAt), nil
+}
+
+// IsStale reports whether the mirror was last refreshed more than maxAge
+// ago, or never.
+func (m *Mirror) IsStale(maxAge time.Duration) (bool, error) {
+	staleness, err := m.Staleness()
+	if err != nil {
+		return false, err
+	}
+	return staleness > maxAge, nil
+}
+
+// Get returns the account with the given ID, or ErrNotFound.
+func (m *Mirror) Get(id string) (*form3.Account, error) {
+	var account *form3.Account
+	err := m.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(accountsBucket).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		account = new(form3.Account)
+		return json.Unmarshal(data, account)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Count returns the number of accounts in the mirror.
+func (m *Mirror) Count() (int, error) {
+	n := 0
+	err := m.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(accountsBucket).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
+
+// versionStore presents the versions of the accounts in a mirror as the
+// state of an account watcher. Saving is a no-op: the state is saved by
+// writing the accounts.
+type versionStore struct {
+	m *Mirror
+}
+
+func (s versionStore) Load() (*form3.WatchState, error) {
+	var state *form3.WatchState
+	err := s.m.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(metaBucket).Get(syncedAtKey)
+		if data == nil {
+			return nil
+		}
+		state = &form3.WatchState{Versions: make(map[string]int)}
+		if err := state.PolledAt.UnmarshalText(data); err != nil {
+			return err
+		}
+		return tx.Bucket(accountsBucket).ForEach(func(k, v []byte) error {
+			var a struct {
+				Version int `json:"version"`
+			}
+			if err := json.Unmarshal(v, &a); err != nil {
+				return err
+			}
+			state.Versions[string(k)] = a.Version
+			return nil
+		})
+	})
+	return state, err
+}
+
+func (s versionStore) Save(*form3.WatchState) error {
+	return nil
+}
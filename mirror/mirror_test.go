@@ -0,0 +1,196 @@
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"form3.tech/go-form3/form3"
+)
+
+// fakeAPI serves a mutable set of accounts from the list and fetch
+// endpoints.
+type fakeAPI struct {
+	mu       sync.Mutex
+	accounts map[string]*form3.Account
+}
+
+func (f *fakeAPI) set(a *form3.Account) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.accounts[*a.ID] = a
+}
+
+func (f *fakeAPI) remove(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.accounts, id)
+}
+
+func setup(t *testing.T, accounts ...*form3.Account) (*Mirror, *fakeAPI) {
+	t.Helper()
+	api := &fakeAPI{accounts: make(map[string]*form3.Account)}
+	for _, a := range accounts {
+		api.set(a)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		var ids []string
+		for id := range api.accounts {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		number, _ := strconv.Atoi(r.URL.Query().Get("page[number]"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("page[size]"))
+		var page []*form3.Account
+		for i := number * size; i < len(ids) && i < (number+1)*size; i++ {
+			page = append(page, api.accounts[ids[i]])
+		}
+		json.NewEncoder(w).Encode(&form3.AccountDetailsListResponse{Data: page})
+	})
+	mux.HandleFunc("/v1/organisation/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		a, ok := api.accounts[strings.TrimPrefix(r.URL.Path, "/v1/organisation/accounts/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(&form3.AccountDetailsResponse{Data: a})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := form3.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/v1/")
+
+	m, err := Open(filepath.Join(t.TempDir(), "accounts.db"), client.Accounts, nil)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m, api
+}
+
+func testAccount(id string, version int, bankID, name string, status form3.AccountStatus) *form3.Account {
+	return &form3.Account{
+		ID:      form3.String(id),
+		Version: form3.Int(version),
+		Attributes: &form3.AccountAttributes{
+			Country:       form3.CountryGB.Ptr(),
+			BankId:        form3.String(bankID),
+			AccountNumber: form3.String("4142681" + id),
+			Name:          []string{name},
+			Status:        status.Ptr(),
+		},
+	}
+}
+
+func TestUnit_Mirror_Refresh(t *testing.T) {
+	m, api := setup(t,
+		testAccount("1", 0, "400300", "Samantha Holder", form3.StatusConfirmed),
+		testAccount("2", 0, "400300", "Jo Bloggs", form3.StatusPending),
+	)
+
+	result, err := m.Refresh(context.Background())
+	if err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	if *result != (RefreshResult{Created: 2}) {
+		t.Errorf("First refresh = %+v, want 2 created", *result)
+	}
+
+	api.set(testAccount("2", 1, "400300", "Jo Bloggs", form3.StatusConfirmed))
+	api.set(testAccount("3", 0, "601613", "Alex Smith", form3.StatusConfirmed))
+	api.remove("1")
+
+	result, err = m.Refresh(context.Background())
+	if err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	if *result != (RefreshResult{Created: 1, Updated: 1, Deleted: 1}) {
+		t.Errorf("Second refresh = %+v, want 1 created, updated and deleted", *result)
+	}
+
+	if n, _ := m.Count(); n != 2 {
+		t.Errorf("Count = %d, want 2", n)
+	}
+	a, err := m.Get("2")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if *a.Version != 1 || *a.Attributes.Status != form3.StatusConfirmed {
+		t.Errorf("Get returned version %d with status %v, want version 1 confirmed", *a.Version, *a.Attributes.Status)
+	}
+	if _, err := m.Get("1"); err != ErrNotFound {
+		t.Errorf("Get of deleted account returned error %v, want ErrNotFound", err)
+	}
+
+	result, err = m.Refresh(context.Background())
+	if err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	if *result != (RefreshResult{}) {
+		t.Errorf("Refresh without changes = %+v, want none", *result)
+	}
+}
+
+func TestUnit_Mirror_Reopen(t *testing.T) {
+	m, api := setup(t, testAccount("1", 0, "400300", "Samantha Holder", form3.StatusConfirmed))
+	if _, err := m.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	path := m.db.Path()
+	accounts := m.accounts
+	m.Close()
+
+	m, err := Open(path, accounts, nil)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer m.Close()
+	api.set(testAccount("1", 3, "400300", "Samantha Holder", form3.StatusConfirmed))
+
+	result, err := m.Refresh(context.Background())
+	if err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	if *result != (RefreshResult{Updated: 1}) {
+		t.Errorf("Refresh after reopening = %+v, want 1 updated", *result)
+	}
+}
+
+func TestUnit_Mirror_Staleness(t *testing.T) {
+	m, _ := setup(t)
+
+	if stale, err := m.IsStale(time.Hour); err != nil || !stale {
+		t.Errorf("IsStale before refreshing = %v, %v, want true", stale, err)
+	}
+
+	before := time.Now()
+	if _, err := m.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	syncedAt, err := m.SyncedAt()
+	if err != nil {
+		t.Fatalf("SyncedAt returned error: %v", err)
+	}
+	if syncedAt.Before(before.Add(-time.Second)) || syncedAt.After(time.Now()) {
+		t.Errorf("SyncedAt = %v, want about %v", syncedAt, before)
+	}
+	if stale, err := m.IsStale(time.Hour); err != nil || stale {
+		t.Errorf("IsStale after refreshing = %v, %v, want false", stale, err)
+	}
+}
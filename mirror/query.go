@@ -0,0 +1,120 @@
+package mirror
+
+import (
+	"encoding/json"
+	"strings"
+
+	"form3.tech/go-form3/form3"
+	bolt "go.etcd.io/bbolt"
+)
+
+// A Query selects the accounts in a mirror matching every field set.
+type Query struct {
+	// Bank ID, such as a UK sort code. Spaces and dashes are ignored, so
+	// "40-03-00" matches "400300".
+	SortCode string
+
+	AccountNumber string
+	CustomerID    string
+	Country       form3.Country
+	Status        form3.AccountStatus
+
+	// Matches accounts with a name or alternative name containing Name,
+	// ignoring case.
+	Name string
+}
+
+// Query returns the accounts matching q, ordered by ID. A nil query
+// returns every account. Queries by sort code or status only read the
+// accounts with it, found in an index; other queries read every account.
+func (m *Mirror) Query(q *Query) ([]*form3.Account, error) {
+	if q == nil {
+		q = new(Query)
+	}
+	var accounts []*form3.Account
+	match := func(data []byte) error {
+		a := new(form3.Account)
+		if err := json.Unmarshal(data, a); err != nil {
+			return err
+		}
+		if q.matches(a) {
+			accounts = append(accounts, a)
+		}
+		return nil
+	}
+	err := m.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(accountsBucket)
+		var ids [][]byte
+		switch {
+		case q.SortCode != "":
+			ids = lookup(tx, bankIDIndex, normaliseSortCode(q.SortCode))
+		case q.Status != "":
+			ids = lookup(tx, statusIndex, string(q.Status))
+		default:
+			return b.ForEach(func(k, v []byte) error {
+				return match(v)
+			})
+		}
+		for _, id := range ids {
+			if data := b.Get(id); data != nil {
+				if err := match(data); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (q *Query) matches(a *form3.Account) bool {
+	attrs := a.Attributes
+	if attrs == nil {
+		attrs = new(form3.AccountAttributes)
+	}
+	if q.SortCode != "" && normaliseSortCode(q.SortCode) != normaliseSortCode(stringValue(attrs.BankId)) {
+		return false
+	}
+	if q.AccountNumber != "" && q.AccountNumber != stringValue(attrs.AccountNumber) {
+		return false
+	}
+	if q.CustomerID != "" && q.CustomerID != stringValue(attrs.CustomerId) {
+		return false
+	}
+	if q.Country != "" && (attrs.Country == nil || *attrs.Country != q.Country) {
+		return false
+	}
+	if q.Status != "" && (attrs.Status == nil || *attrs.Status != q.Status) {
+		return false
+	}
+	if q.Name != "" && !containsName(attrs, strings.ToLower(q.Name)) {
+		return false
+	}
+	return true
+}
+
+// containsName reports whether any of the names of an account contain name,
+// which must be lower case.
+func containsName(attrs *form3.AccountAttributes, name string) bool {
+	names := append(append([]string(nil), attrs.Name...), attrs.AlternativeNames...)
+	for _, n := range names {
+		if strings.Contains(strings.ToLower(n), name) {
+			return true
+		}
+	}
+	return false
+}
+
+func normaliseSortCode(s string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(s)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,119 @@
+package mirror
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"form3.tech/go-form3/form3"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestUnit_Mirror_Query(t *testing.T) {
+	alternative := testAccount("4", 0, "601613", "Acme Ltd", form3.StatusConfirmed)
+	alternative.Attributes.AlternativeNames = []string{"Samantha Holder Trading"}
+	m, _ := setup(t,
+		testAccount("1", 0, "400300", "Samantha Holder", form3.StatusConfirmed),
+		testAccount("2", 0, "400300", "Jo Bloggs", form3.StatusPending),
+		testAccount("3", 0, "601613", "Alex Smith", form3.StatusConfirmed),
+		alternative,
+	)
+	if _, err := m.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query *Query
+		want  []string
+	}{
+		{"all", nil, []string{"1", "2", "3", "4"}},
+		{"sort code", &Query{SortCode: "40-03-00"}, []string{"1", "2"}},
+		{"status", &Query{Status: form3.StatusConfirmed}, []string{"1", "3", "4"}},
+		{"name", &Query{Name: "samantha"}, []string{"1", "4"}},
+		{"combined", &Query{SortCode: "601613", Name: "holder"}, []string{"4"}},
+		{"account number", &Query{AccountNumber: "41426813"}, []string{"3"}},
+		{"country", &Query{Country: form3.CountryFR}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts, err := m.Query(tt.query)
+			if err != nil {
+				t.Fatalf("Query returned error: %v", err)
+			}
+			var ids []string
+			for _, a := range accounts {
+				ids = append(ids, *a.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("Query returned %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnit_Mirror_Query_Indexes(t *testing.T) {
+	m, api := setup(t,
+		testAccount("1", 0, "400300", "Samantha Holder", form3.StatusConfirmed),
+		testAccount("2", 0, "400300", "Jo Bloggs", form3.StatusPending),
+		testAccount("3", 0, "601613", "Alex Smith", form3.StatusConfirmed),
+	)
+	if _, err := m.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	api.set(testAccount("1", 1, "601613", "Samantha Holder", form3.StatusConfirmed))
+	api.set(testAccount("2", 1, "400300", "Jo Bloggs", form3.StatusConfirmed))
+	api.remove("3")
+	if _, err := m.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	query := func(q *Query) []string {
+		t.Helper()
+		accounts, err := m.Query(q)
+		if err != nil {
+			t.Fatalf("Query returned error: %v", err)
+		}
+		var ids []string
+		for _, a := range accounts {
+			ids = append(ids, *a.ID)
+		}
+		return ids
+	}
+	check := func(when string) {
+		t.Helper()
+		tests := []struct {
+			query *Query
+			want  []string
+		}{
+			{&Query{SortCode: "40-03-00"}, []string{"2"}},
+			{&Query{SortCode: "601613"}, []string{"1"}},
+			{&Query{Status: form3.StatusConfirmed}, []string{"1", "2"}},
+			{&Query{Status: form3.StatusPending}, nil},
+		}
+		for _, tt := range tests {
+			if got := query(tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Query(%+v) %s returned %v, want %v", *tt.query, when, got, tt.want)
+			}
+		}
+	}
+	check("after refreshing")
+
+	// A mirror written without the indexes has them built when opened.
+	err := m.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bankIDIndex); err != nil {
+			return err
+		}
+		return tx.DeleteBucket(statusIndex)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := m.db.Path()
+	m.Close()
+	if m, err = Open(path, m.accounts, nil); err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer m.Close()
+	check("after reopening without indexes")
+}
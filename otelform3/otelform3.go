@@ -0,0 +1,191 @@
+// Package otelform3 instruments the Form3 API client with OpenTelemetry.
+//
+// It provides an http.RoundTripper, and an equivalent form3.Middleware, that
+// records a client span for every API call, named after the operation being
+// performed (e.g. "accounts.create"), propagates the trace context in the
+// request headers and records request count and latency metrics per
+// operation:
+//
+//	httpClient := &http.Client{Transport: otelform3.NewTransport(nil)}
+//	client := form3.NewClient(httpClient)
+package otelform3
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"form3.tech/go-form3/form3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "form3.tech/go-form3/otelform3"
+
+// Attribute keys recorded on spans and metrics.
+const (
+	OperationKey  = attribute.Key("form3.operation")
+	ErrorCodeKey  = attribute.Key("form3.error_code")
+	MethodKey     = attribute.Key("http.request.method")
+	StatusCodeKey = attribute.Key("http.response.status_code")
+	URLKey        = attribute.Key("url.full")
+)
+
+// unknownOperation names spans of requests not made by a service method.
+const unknownOperation = "unknown"
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagators    propagation.TextMapPropagator
+}
+
+// An Option configures the instrumentation.
+type Option func(*config)
+
+// WithTracerProvider sets the provider used to create the tracer. The global
+// provider is used by default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) { c.tracerProvider = provider }
+}
+
+// WithMeterProvider sets the provider used to create the meter. The global
+// provider is used by default.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) { c.meterProvider = provider }
+}
+
+// WithPropagators sets the propagators used to inject the trace context into
+// request headers. The global propagators are used by default.
+func WithPropagators(propagators propagation.TextMapPropagator) Option {
+	return func(c *config) { c.propagators = propagators }
+}
+
+// Transport is an http.RoundTripper that instruments requests to the Form3
+// API.
+type Transport struct {
+	base        http.RoundTripper
+	tracer      trace.Tracer
+	propagators propagation.TextMapPropagator
+	requests    metric.Int64Counter
+	duration    metric.Float64Histogram
+}
+
+// NewTransport returns a Transport wrapping base. If base is nil,
+// http.DefaultTransport is used.
+func NewTransport(base http.RoundTripper, opts ...Option) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	cfg := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagators:    otel.GetTextMapPropagator(),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	meter := cfg.meterProvider.Meter(instrumentationName)
+	// Creating instruments only fails for invalid names, in which case a
+	// no-op instrument is returned, so the errors are ignored.
+	requests, _ := meter.Int64Counter("form3.client.requests",
+		metric.WithDescription("Number of requests made to the Form3 API."),
+		metric.WithUnit("{request}"))
+	duration, _ := meter.Float64Histogram("form3.client.duration",
+		metric.WithDescription("Duration of requests made to the Form3 API."),
+		metric.WithUnit("s"))
+
+	return &Transport{
+		base:        base,
+		tracer:      cfg.tracerProvider.Tracer(instrumentationName),
+		propagators: cfg.propagators,
+		requests:    requests,
+		duration:    duration,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation, ok := form3.OperationFromContext(req.Context())
+	if !ok {
+		operation = unknownOperation
+	}
+
+	ctx, span := t.tracer.Start(req.Context(), operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			OperationKey.String(operation),
+			MethodKey.String(req.Method),
+			URLKey.String(form3.RedactURL(req.URL)),
+		))
+	defer span.End()
+
+	// RoundTrippers must not modify the request they are given.
+	req = req.Clone(ctx)
+	t.propagators.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	attrs := []attribute.KeyValue{OperationKey.String(operation), MethodKey.String(req.Method)}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		attrs = append(attrs, StatusCodeKey.Int(resp.StatusCode))
+		span.SetAttributes(StatusCodeKey.Int(resp.StatusCode))
+		if resp.StatusCode >= 400 {
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+			if code := peekErrorCode(resp); code != "" {
+				span.SetAttributes(ErrorCodeKey.String(code))
+			}
+		}
+	}
+
+	set := metric.WithAttributes(attrs...)
+	t.requests.Add(ctx, 1, set)
+	t.duration.Record(ctx, elapsed.Seconds(), set)
+
+	return resp, err
+}
+
+// Middleware returns a form3.Middleware that instruments requests in the same
+// way as Transport, for use with form3.WithMiddleware:
+//
+//	client, err := form3.NewClientWithOptions(form3.WithMiddleware(otelform3.Middleware()))
+func Middleware(opts ...Option) form3.Middleware {
+	return func(next form3.Doer) form3.Doer {
+		return form3.DoerFunc(NewTransport(roundTripperFunc(next.Do), opts...).RoundTrip)
+	}
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// peekErrorCode returns the Form3 error code in the body of an error
+// response, leaving the body intact for the client to read.
+func peekErrorCode(resp *http.Response) string {
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return ""
+	}
+
+	errorResponse := new(form3.ErrorResponse)
+	if json.Unmarshal(data, errorResponse) != nil {
+		return ""
+	}
+	return errorResponse.Code
+}
@@ -0,0 +1,197 @@
+package otelform3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"form3.tech/go-form3/form3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setupInstrumentedClient(t *testing.T, handler http.HandlerFunc) (*form3.Client, *tracetest.InMemoryExporter, *sdkmetric.ManualReader) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	transport := NewTransport(nil,
+		WithTracerProvider(tracerProvider),
+		WithMeterProvider(meterProvider),
+		WithPropagators(propagation.TraceContext{}))
+
+	client := form3.NewClient(&http.Client{Transport: transport})
+	client.BaseURL, _ = url.Parse(server.URL + "/v1/")
+	return client, exporter, reader
+}
+
+func attributeValue(attrs []attribute.KeyValue, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestUnit_Transport_Span(t *testing.T) {
+	var traceparent string
+	client, exporter, _ := setupInstrumentedClient(t, func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		fmt.Fprint(w, `{"data": {"id": "d97a4470-299f-11eb-adc1-0242ac120002"}}`)
+	})
+
+	_, _, err := client.Accounts.Fetch(context.Background(), "d97a4470-299f-11eb-adc1-0242ac120002")
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Recorded %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if got, want := span.Name, "accounts.fetch"; got != want {
+		t.Errorf("Span name = %v, want %v", got, want)
+	}
+	if got, ok := attributeValue(span.Attributes, StatusCodeKey); !ok || got.AsInt64() != 200 {
+		t.Errorf("Span status code = %v, want 200", got.AsInt64())
+	}
+	if span.Status.Code == codes.Error {
+		t.Errorf("Span status = %v, want unset", span.Status.Code)
+	}
+
+	want := fmt.Sprintf("00-%s-%s-01", span.SpanContext.TraceID(), span.SpanContext.SpanID())
+	if traceparent != want {
+		t.Errorf("traceparent header = %q, want %q", traceparent, want)
+	}
+}
+
+func TestUnit_Transport_ErrorCode(t *testing.T) {
+	client, exporter, _ := setupInstrumentedClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `{"error_message": "Account cannot be created as it violates a duplicate constraint", "error_code": "duplicate_account"}`)
+	})
+
+	_, _, err := client.Accounts.Create(context.Background(), &form3.Account{})
+	if err == nil {
+		t.Fatal("Create did not return error")
+	}
+	if errorResponse, ok := err.(*form3.ErrorResponse); !ok || errorResponse.Code != "duplicate_account" {
+		t.Errorf("Create returned error %v, want decoded error response", err)
+	}
+
+	span := exporter.GetSpans()[0]
+	if got, want := span.Name, "accounts.create"; got != want {
+		t.Errorf("Span name = %v, want %v", got, want)
+	}
+	if got, _ := attributeValue(span.Attributes, ErrorCodeKey); got.AsString() != "duplicate_account" {
+		t.Errorf("Span error code = %q, want %q", got.AsString(), "duplicate_account")
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("Span status = %v, want %v", span.Status.Code, codes.Error)
+	}
+}
+
+func TestUnit_Transport_Metrics(t *testing.T) {
+	client, _, reader := setupInstrumentedClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": []}`)
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := client.Accounts.List(context.Background(), nil); err != nil {
+			t.Fatalf("List returned error: %v", err)
+		}
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect returned error: %v", err)
+	}
+
+	var requests int64
+	var durations uint64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					if op, _ := dp.Attributes.Value(OperationKey); op.AsString() == "accounts.list" {
+						requests += dp.Value
+					}
+				}
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					if op, _ := dp.Attributes.Value(OperationKey); op.AsString() == "accounts.list" {
+						durations += dp.Count
+					}
+				}
+			}
+		}
+	}
+	if requests != 3 {
+		t.Errorf("Request count = %d, want 3", requests)
+	}
+	if durations != 3 {
+		t.Errorf("Duration count = %d, want 3", durations)
+	}
+}
+
+func TestUnit_Middleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	client, err := form3.NewClientWithOptions(form3.WithMiddleware(Middleware(WithTracerProvider(tracerProvider))))
+	if err != nil {
+		t.Fatalf("NewClientWithOptions returned error: %v", err)
+	}
+	client.BaseURL, _ = url.Parse(server.URL + "/v1/")
+
+	if _, err := client.Accounts.Delete(context.Background(), "d97a4470-299f-11eb-adc1-0242ac120002", 1); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "accounts.delete" {
+		t.Errorf("Recorded spans %v, want one accounts.delete span", spans)
+	}
+}
+
+func TestUnit_Transport_RedactsURL(t *testing.T) {
+	client, exporter, _ := setupInstrumentedClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": []}`)
+	})
+
+	_, err := client.Accounts.FindByIBAN(context.Background(), "GB11NWBK40030041426819")
+	if _, ok := err.(*form3.AccountNotFoundError); !ok {
+		t.Fatalf("FindByIBAN returned error %v, want *form3.AccountNotFoundError", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Recorded %d spans, want 1", len(spans))
+	}
+	got, _ := attributeValue(spans[0].Attributes, URLKey)
+	if strings.Contains(got.AsString(), "GB11NWBK40030041426819") || !strings.Contains(got.AsString(), "REDACTED") {
+		t.Errorf("Span URL = %v, want the IBAN filter redacted", got.AsString())
+	}
+}
@@ -0,0 +1,368 @@
+package snapshot
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"form3.tech/go-form3/form3"
+)
+
+// columns names the columns of the CSV and columnar formats, in order. Each
+// is a top level member of an account or one of its attributes.
+var columns = []string{
+	"id",
+	"type",
+	"organisation_id",
+	"version",
+	"country",
+	"base_currency",
+	"account_number",
+	"bank_id",
+	"bank_id_code",
+	"bic",
+	"iban",
+	"customer_id",
+	"name",
+	"alternative_names",
+	"account_classification",
+	"joint_account",
+	"account_matching_opt_out",
+	"secondary_identification",
+	"switched",
+	"status",
+	"title",
+	"first_name",
+	"bank_account_name",
+	"alternative_bank_account_names",
+}
+
+// topLevelColumns are the columns that are not account attributes.
+var topLevelColumns = map[string]bool{
+	"id":              true,
+	"type":            true,
+	"organisation_id": true,
+	"version":         true,
+}
+
+// rawColumns are the columns whose CSV cells hold JSON rather than a string.
+var rawColumns = map[string]bool{
+	"version":                  true,
+	"name":                     true,
+	"alternative_names":        true,
+	"joint_account":            true,
+	"account_matching_opt_out": true,
+	"switched":                 true,
+}
+
+// A record is an account flattened into its columns. Missing values are
+// absent or null.
+type record map[string]json.RawMessage
+
+// toRecord flattens a into a record.
+func toRecord(a *form3.Account) (record, error) {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	attrs := rec["attributes"]
+	delete(rec, "attributes")
+	if len(attrs) > 0 && string(attrs) != "null" {
+		var attributes record
+		if err := json.Unmarshal(attrs, &attributes); err != nil {
+			return nil, err
+		}
+		for k, v := range attributes {
+			rec[k] = v
+		}
+	}
+	return rec, nil
+}
+
+// toAccount rebuilds the account flattened into rec.
+func (rec record) toAccount() (*form3.Account, error) {
+	top := make(record)
+	attributes := make(record)
+	for k, v := range rec {
+		if isNull(v) {
+			continue
+		}
+		if topLevelColumns[k] {
+			top[k] = v
+		} else {
+			attributes[k] = v
+		}
+	}
+	attrs, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, err
+	}
+	top["attributes"] = attrs
+
+	data, err := json.Marshal(top)
+	if err != nil {
+		return nil, err
+	}
+	a := new(form3.Account)
+	if err := json.Unmarshal(data, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// cell returns the CSV cell of column col.
+func (rec record) cell(col string) (string, error) {
+	v := rec[col]
+	if isNull(v) {
+		return "", nil
+	}
+	if rawColumns[col] {
+		return string(v), nil
+	}
+	var s string
+	if err := json.Unmarshal(v, &s); err != nil {
+		return "", fmt.Errorf("column %s: %v", col, err)
+	}
+	return s, nil
+}
+
+// setCell sets column col from its CSV cell.
+func (rec record) setCell(col, cell string) error {
+	if cell == "" {
+		return nil
+	}
+	if rawColumns[col] {
+		if !json.Valid([]byte(cell)) {
+			return fmt.Errorf("column %s: invalid JSON %q", col, cell)
+		}
+		rec[col] = json.RawMessage(cell)
+		return nil
+	}
+	rec[col] = json.RawMessage(strconv.Quote(cell))
+	return nil
+}
+
+func isNull(v json.RawMessage) bool {
+	return len(v) == 0 || string(v) == "null"
+}
+
+// An encoder writes accounts in one of the snapshot formats.
+type encoder interface {
+	begin(w io.Writer) error
+	encode(a *form3.Account) error
+	end() error
+}
+
+// A decoder reads all the accounts in a data file.
+type decoder interface {
+	decode(r io.Reader) ([]*form3.Account, error)
+}
+
+func newEncoder(format Format) (encoder, error) {
+	switch format {
+	case FormatJSONLines:
+		return new(jsonLinesCodec), nil
+	case FormatCSV:
+		return new(csvCodec), nil
+	case FormatColumnar:
+		return new(columnarCodec), nil
+	}
+	return nil, fmt.Errorf("unknown snapshot format %q", format)
+}
+
+func newDecoder(format Format) (decoder, error) {
+	switch format {
+	case FormatJSONLines:
+		return new(jsonLinesCodec), nil
+	case FormatCSV:
+		return new(csvCodec), nil
+	case FormatColumnar:
+		return new(columnarCodec), nil
+	}
+	return nil, fmt.Errorf("unknown snapshot format %q", format)
+}
+
+// jsonLinesCodec encodes each account as a JSON object on its own line.
+type jsonLinesCodec struct {
+	enc *json.Encoder
+}
+
+func (c *jsonLinesCodec) begin(w io.Writer) error {
+	c.enc = json.NewEncoder(w)
+	return nil
+}
+
+func (c *jsonLinesCodec) encode(a *form3.Account) error {
+	return c.enc.Encode(a)
+}
+
+func (c *jsonLinesCodec) end() error {
+	return nil
+}
+
+func (c *jsonLinesCodec) decode(r io.Reader) ([]*form3.Account, error) {
+	var accounts []*form3.Account
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		a := new(form3.Account)
+		if err := json.Unmarshal(scanner.Bytes(), a); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		accounts = append(accounts, a)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// csvCodec encodes each account as a row of columns, after a header row.
+type csvCodec struct {
+	w *csv.Writer
+}
+
+func (c *csvCodec) begin(w io.Writer) error {
+	c.w = csv.NewWriter(w)
+	return c.w.Write(columns)
+}
+
+func (c *csvCodec) encode(a *form3.Account) error {
+	rec, err := toRecord(a)
+	if err != nil {
+		return err
+	}
+	row := make([]string, len(columns))
+	for i, col := range columns {
+		if row[i], err = rec.cell(col); err != nil {
+			return err
+		}
+	}
+	return c.w.Write(row)
+}
+
+func (c *csvCodec) end() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvCodec) decode(r io.Reader) ([]*form3.Account, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []*form3.Account
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return accounts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		rec := make(record)
+		for i, col := range header {
+			if err := rec.setCell(col, row[i]); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+		a, err := rec.toAccount()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		accounts = append(accounts, a)
+	}
+}
+
+// columnarCodec encodes all the accounts as one JSON document holding an
+// array of values per column. Accounts are buffered until end is called.
+type columnarCodec struct {
+	w       io.Writer
+	records []record
+}
+
+// columnarDocument is the JSON document written by columnarCodec.
+type columnarDocument struct {
+	Count   int           `json:"count"`
+	Columns []*columnData `json:"columns"`
+}
+
+type columnData struct {
+	Name   string            `json:"name"`
+	Values []json.RawMessage `json:"values"`
+}
+
+func (c *columnarCodec) begin(w io.Writer) error {
+	c.w = w
+	return nil
+}
+
+func (c *columnarCodec) encode(a *form3.Account) error {
+	rec, err := toRecord(a)
+	if err != nil {
+		return err
+	}
+	c.records = append(c.records, rec)
+	return nil
+}
+
+func (c *columnarCodec) end() error {
+	doc := &columnarDocument{Count: len(c.records)}
+	for _, col := range columns {
+		data := &columnData{Name: col, Values: make([]json.RawMessage, len(c.records))}
+		for i, rec := range c.records {
+			v := rec[col]
+			if isNull(v) {
+				v = json.RawMessage("null")
+			}
+			data.Values[i] = v
+		}
+		doc.Columns = append(doc.Columns, data)
+	}
+	return json.NewEncoder(c.w).Encode(doc)
+}
+
+func (c *columnarCodec) decode(r io.Reader) ([]*form3.Account, error) {
+	doc := new(columnarDocument)
+	if err := json.NewDecoder(r).Decode(doc); err != nil {
+		return nil, err
+	}
+	records := make([]record, doc.Count)
+	for i := range records {
+		records[i] = make(record)
+	}
+	for _, col := range doc.Columns {
+		if len(col.Values) != doc.Count {
+			return nil, fmt.Errorf("column %s has %d values, want %d", col.Name, len(col.Values), doc.Count)
+		}
+		for i, v := range col.Values {
+			records[i][col.Name] = v
+		}
+	}
+
+	accounts := make([]*form3.Account, len(records))
+	for i, rec := range records {
+		a, err := rec.toAccount()
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %v", i, err)
+		}
+		accounts[i] = a
+	}
+	return accounts, nil
+}
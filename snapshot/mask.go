@@ -0,0 +1,65 @@
+package snapshot
+
+import (
+	"strings"
+
+	"form3.tech/go-form3/form3"
+)
+
+// maskedName replaces names in masked snapshots.
+const maskedName = "[MASKED]"
+
+// visibleDigits is the number of trailing characters of account numbers and
+// IBANs left visible by masking.
+const visibleDigits = 4
+
+// maskAccount returns a copy of a with its account numbers, IBAN, names and
+// secondary identification masked.
+func maskAccount(a *form3.Account) *form3.Account {
+	m := *a
+	if a.Attributes == nil {
+		return &m
+	}
+	attrs := *a.Attributes
+	attrs.AccountNumber = maskNumber(attrs.AccountNumber)
+	attrs.IBAN = maskNumber(attrs.IBAN)
+	attrs.SecondaryIdentification = maskNumber(attrs.SecondaryIdentification)
+	attrs.Name = maskNames(attrs.Name)
+	attrs.AlternativeNames = maskNames(attrs.AlternativeNames)
+	attrs.Title = maskString(attrs.Title)
+	attrs.FirstName = maskString(attrs.FirstName)
+	attrs.BankAccountName = maskString(attrs.BankAccountName)
+	attrs.AlternativeBankAccountNames = maskString(attrs.AlternativeBankAccountNames)
+	m.Attributes = &attrs
+	return &m
+}
+
+// maskNumber replaces all but the last few characters of s with asterisks.
+func maskNumber(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	if len(*s) <= visibleDigits {
+		return form3.String(strings.Repeat("*", len(*s)))
+	}
+	n := len(*s) - visibleDigits
+	return form3.String(strings.Repeat("*", n) + (*s)[n:])
+}
+
+func maskString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	return form3.String(maskedName)
+}
+
+func maskNames(names []string) []string {
+	if names == nil {
+		return nil
+	}
+	masked := make([]string, len(names))
+	for i := range names {
+		masked[i] = maskedName
+	}
+	return masked
+}
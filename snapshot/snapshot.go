@@ -0,0 +1,265 @@
+// Package snapshot exports point-in-time snapshots of the accounts in a
+// Form3 organisation, and imports them into another environment.
+//
+// A snapshot is a data file holding every account, in JSON lines, CSV or a
+// columnar JSON format, and a manifest recording the number of accounts, the
+// export time and a SHA-256 checksum of the data file:
+//
+//	manifest, err := snapshot.ExportToFile(ctx, client.Accounts, "accounts.jsonl", nil)
+//	...
+//	snap, err := snapshot.Open("accounts.jsonl")
+//	result, err := snapshot.Import(ctx, otherClient.Accounts, snap, nil)
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"form3.tech/go-form3/form3"
+)
+
+// A Format is the encoding of the accounts in a snapshot data file.
+type Format string
+
+// Snapshot formats.
+const (
+	// One JSON encoded account per line.
+	FormatJSONLines Format = "jsonl"
+
+	// One account per row, with a header row naming the columns. Account
+	// attributes are flattened into columns; lists are JSON encoded.
+	FormatCSV Format = "csv"
+
+	// A JSON document holding one array of values per column, like a
+	// Parquet file, for loading into analytical tools.
+	FormatColumnar Format = "columnar"
+)
+
+// manifestSuffix is appended to the data file path to name the manifest.
+const manifestSuffix = ".manifest.json"
+
+// A Manifest describes a snapshot data file.
+type Manifest struct {
+	Format     Format    `json:"format"`
+	Count      int       `json:"count"`
+	ExportedAt time.Time `json:"exported_at"`
+	SHA256     string    `json:"sha256"` // hex encoded checksum of the data file
+	Masked     bool      `json:"masked"` // whether sensitive attributes are masked
+}
+
+// ExportOptions specifies the optional parameters to Export.
+type ExportOptions struct {
+	// Encoding of the data file. Defaults to FormatJSONLines.
+	Format Format
+
+	// If true, account numbers, IBANs and names are masked. Masked
+	// snapshots cannot be imported.
+	Mask bool
+
+	// Exports only the accounts matching the filter.
+	Filter *form3.AccountFilter
+
+	// Number of accounts requested per page. Defaults to 100.
+	PageSize int
+}
+
+// A Snapshot is a set of accounts read from a snapshot data file.
+type Snapshot struct {
+	Manifest Manifest
+	Accounts []*form3.Account
+}
+
+// ManifestPath returns the path of the manifest of the data file at path.
+func ManifestPath(path string) string {
+	return path + manifestSuffix
+}
+
+// Export writes every account listed by accounts to w and returns the
+// manifest of the data written.
+func Export(ctx context.Context, accounts *form3.AccountsService, w io.Writer, opts *ExportOptions) (*Manifest, error) {
+	o := ExportOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Format == "" {
+		o.Format = FormatJSONLines
+	}
+	enc, err := newEncoder(o.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	exportedAt := time.Now().UTC()
+	hash := sha256.New()
+	out := io.MultiWriter(w, hash)
+	if err := enc.begin(out); err != nil {
+		return nil, err
+	}
+
+	count := 0
+	listOpts := &form3.AccountListOptions{ListOptions: form3.ListOptions{PageSize: o.PageSize}, Filter: o.Filter}
+	err = accounts.ListAll(ctx, listOpts, func(a *form3.Account) error {
+		if o.Mask {
+			a = maskAccount(a)
+		}
+		count++
+		return enc.encode(a)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := enc.end(); err != nil {
+		return nil, err
+	}
+
+	return &Manifest{
+		Format:     o.Format,
+		Count:      count,
+		ExportedAt: exportedAt,
+		SHA256:     hex.EncodeToString(hash.Sum(nil)),
+		Masked:     o.Mask,
+	}, nil
+}
+
+// ExportToFile exports accounts to a data file at path, and writes its
+// manifest next to it at ManifestPath(path).
+func ExportToFile(ctx context.Context, accounts *form3.AccountsService, path string, opts *ExportOptions) (*Manifest, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := Export(ctx, accounts, f, opts)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(ManifestPath(path), append(data, '\n'), 0644); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// Read reads the accounts in a data file in the given format, without
+// checking it against a manifest.
+func Read(r io.Reader, format Format) ([]*form3.Account, error) {
+	dec, err := newDecoder(format)
+	if err != nil {
+		return nil, err
+	}
+	return dec.decode(r)
+}
+
+// Open reads the snapshot with its data file at path, checking it against
+// the manifest at ManifestPath(path).
+func Open(path string) (*Snapshot, error) {
+	data, err := ioutil.ReadFile(ManifestPath(path))
+	if err != nil {
+		return nil, err
+	}
+	snap := new(Snapshot)
+	if err := json.Unmarshal(data, &snap.Manifest); err != nil {
+		return nil, fmt.Errorf("reading manifest: %v", err)
+	}
+
+	data, err = ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != snap.Manifest.SHA256 {
+		return nil, fmt.Errorf("checksum of %s is %s, but the manifest records %s", path, got, snap.Manifest.SHA256)
+	}
+
+	dec, err := newDecoder(snap.Manifest.Format)
+	if err != nil {
+		return nil, err
+	}
+	snap.Accounts, err = dec.decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(snap.Accounts) != snap.Manifest.Count {
+		return nil, fmt.Errorf("%s holds %d accounts, but the manifest records %d", path, len(snap.Accounts), snap.Manifest.Count)
+	}
+	return snap, nil
+}
+
+// ImportOptions specifies the optional parameters to Import.
+type ImportOptions struct {
+	// Creates the accounts in this organisation instead of the one they
+	// were exported from.
+	OrganisationID string
+
+	// If true, the accounts are given new IDs instead of keeping the IDs
+	// they were exported with.
+	NewIDs bool
+}
+
+// An ImportResult is the outcome of Import.
+type ImportResult struct {
+	// IDs of the accounts created, in snapshot order.
+	Created []string
+
+	// Accounts that could not be created, by their ID in the snapshot.
+	Failed map[string]error
+}
+
+// Import creates the accounts in snap using accounts. Accounts that fail to
+// be created are reported in the result; an error is returned only if the
+// snapshot cannot be imported at all or ctx is done.
+func Import(ctx context.Context, accounts *form3.AccountsService, snap *Snapshot, opts *ImportOptions) (*ImportResult, error) {
+	if snap.Manifest.Masked {
+		return nil, errors.New("masked snapshots cannot be imported")
+	}
+	o := ImportOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	result := &ImportResult{Failed: make(map[string]error)}
+	for _, a := range snap.Accounts {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		account := *a
+		account.Version = nil
+		if o.OrganisationID != "" {
+			account.OrganisationId = form3.String(o.OrganisationID)
+		}
+		if o.NewIDs {
+			account.ID = nil
+		}
+
+		created, _, err := accounts.Create(ctx, &account)
+		if err != nil {
+			result.Failed[stringValue(a.ID)] = err
+			continue
+		}
+		result.Created = append(result.Created, stringValue(created.ID))
+	}
+	return result, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
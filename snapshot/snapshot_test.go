@@ -0,0 +1,262 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+
+	"form3.tech/go-form3/form3"
+)
+
+var testAccounts = []*form3.Account{
+	{
+		Type:           form3.String("accounts"),
+		ID:             form3.String("ad27e265-9605-4b4b-a0e5-3003ea9cc4dc"),
+		OrganisationId: form3.String("eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"),
+		Version:        form3.Int(0),
+		Attributes: &form3.AccountAttributes{
+			Country:       form3.CountryGB.Ptr(),
+			BaseCurrency:  form3.CurrencyGBP.Ptr(),
+			BankId:        form3.String("400300"),
+			BankIdCode:    form3.BankIdCodeGBDSC.Ptr(),
+			BIC:           form3.String("NWBKGB22"),
+			AccountNumber: form3.String("41426819"),
+			IBAN:          form3.String("GB11NWBK40030041426819"),
+			Name:          []string{"Samantha Holder", "Trading as \"Sam's\", Ltd"},
+			JointAccount:  form3.Bool(true),
+			Status:        form3.StatusConfirmed.Ptr(),
+		},
+	},
+	{
+		Type:           form3.String("accounts"),
+		ID:             form3.String("0f8e9f8e-1b7a-4a3c-9d4e-5f6a7b8c9d0e"),
+		OrganisationId: form3.String("eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"),
+		Version:        form3.Int(3),
+		Attributes: &form3.AccountAttributes{
+			Country:          form3.CountryFR.Ptr(),
+			BankId:           form3.String("20041"),
+			AlternativeNames: []string{"S Holder"},
+			FirstName:        form3.String("Samantha"),
+		},
+	},
+}
+
+// setupClient returns a client for a fake API serving accounts from the
+// list endpoint and recording accounts created.
+func setupClient(t *testing.T, accounts []*form3.Account) (*form3.Client, func() []*form3.Account) {
+	t.Helper()
+	var mu sync.Mutex
+	var created []*form3.Account
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/organisation/accounts", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			body := new(form3.AccountCreation)
+			if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+				t.Errorf("Decoding request body: %v", err)
+			}
+			mu.Lock()
+			created = append(created, body.Data)
+			mu.Unlock()
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(&form3.AccountCreationResponse{Data: body.Data})
+			return
+		}
+
+		q := r.URL.Query()
+		number, _ := strconv.Atoi(q.Get("page[number]"))
+		size, _ := strconv.Atoi(q.Get("page[size]"))
+		start, end := number*size, (number+1)*size
+		if start > len(accounts) {
+			start = len(accounts)
+		}
+		if end > len(accounts) {
+			end = len(accounts)
+		}
+		json.NewEncoder(w).Encode(&form3.AccountDetailsListResponse{Data: accounts[start:end]})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := form3.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/v1/")
+	return client, func() []*form3.Account {
+		mu.Lock()
+		defer mu.Unlock()
+		return created
+	}
+}
+
+func TestUnit_Export_RoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatJSONLines, FormatCSV, FormatColumnar} {
+		t.Run(string(format), func(t *testing.T) {
+			client, _ := setupClient(t, testAccounts)
+
+			var buf bytes.Buffer
+			manifest, err := Export(context.Background(), client.Accounts, &buf, &ExportOptions{Format: format, PageSize: 1})
+			if err != nil {
+				t.Fatalf("Export returned error: %v", err)
+			}
+			if manifest.Count != len(testAccounts) {
+				t.Errorf("Manifest count = %d, want %d", manifest.Count, len(testAccounts))
+			}
+			if manifest.Format != format {
+				t.Errorf("Manifest format = %v, want %v", manifest.Format, format)
+			}
+			sum := sha256.Sum256(buf.Bytes())
+			if want := hex.EncodeToString(sum[:]); manifest.SHA256 != want {
+				t.Errorf("Manifest checksum = %v, want %v", manifest.SHA256, want)
+			}
+			if manifest.ExportedAt.IsZero() {
+				t.Errorf("Manifest export time is not set")
+			}
+
+			accounts, err := Read(&buf, format)
+			if err != nil {
+				t.Fatalf("Read returned error: %v", err)
+			}
+			if !reflect.DeepEqual(accounts, testAccounts) {
+				got, _ := json.Marshal(accounts)
+				want, _ := json.Marshal(testAccounts)
+				t.Errorf("Read returned %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestUnit_Export_Mask(t *testing.T) {
+	client, _ := setupClient(t, testAccounts)
+
+	var buf bytes.Buffer
+	manifest, err := Export(context.Background(), client.Accounts, &buf, &ExportOptions{Mask: true})
+	if err != nil {
+		t.Fatalf("Export returned error: %v", err)
+	}
+	if !manifest.Masked {
+		t.Errorf("Manifest is not marked as masked")
+	}
+
+	accounts, err := Read(&buf, FormatJSONLines)
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	attrs := accounts[0].Attributes
+	if got, want := *attrs.AccountNumber, "****6819"; got != want {
+		t.Errorf("Account number = %v, want %v", got, want)
+	}
+	if got, want := *attrs.IBAN, "******************6819"; got != want {
+		t.Errorf("IBAN = %v, want %v", got, want)
+	}
+	if want := []string{maskedName, maskedName}; !reflect.DeepEqual(attrs.Name, want) {
+		t.Errorf("Name = %v, want %v", attrs.Name, want)
+	}
+	if got := *accounts[1].Attributes.FirstName; got != maskedName {
+		t.Errorf("First name = %v, want %v", got, maskedName)
+	}
+	if got := *testAccounts[0].Attributes.AccountNumber; got != "41426819" {
+		t.Errorf("Masking modified the listed account number to %v", got)
+	}
+}
+
+func TestUnit_ExportToFile_Open(t *testing.T) {
+	client, _ := setupClient(t, testAccounts)
+	path := filepath.Join(t.TempDir(), "accounts.csv")
+
+	manifest, err := ExportToFile(context.Background(), client.Accounts, path, &ExportOptions{Format: FormatCSV})
+	if err != nil {
+		t.Fatalf("ExportToFile returned error: %v", err)
+	}
+
+	snap, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	if !snap.Manifest.ExportedAt.Equal(manifest.ExportedAt) || snap.Manifest.SHA256 != manifest.SHA256 {
+		t.Errorf("Open read manifest %+v, want %+v", snap.Manifest, *manifest)
+	}
+	if !reflect.DeepEqual(snap.Accounts, testAccounts) {
+		t.Errorf("Open read %d accounts, want %v", len(snap.Accounts), testAccounts)
+	}
+}
+
+func TestUnit_Open_ChecksumMismatch(t *testing.T) {
+	client, _ := setupClient(t, testAccounts)
+	path := filepath.Join(t.TempDir(), "accounts.jsonl")
+
+	if _, err := ExportToFile(context.Background(), client.Accounts, path, nil); err != nil {
+		t.Fatalf("ExportToFile returned error: %v", err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if err := ioutil.WriteFile(path, bytes.Replace(data, []byte("41426819"), []byte("41426810"), 1), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(path); err == nil {
+		t.Errorf("Expected error opening a modified data file")
+	}
+}
+
+func TestUnit_Import(t *testing.T) {
+	client, created := setupClient(t, nil)
+	snap := &Snapshot{Manifest: Manifest{Count: len(testAccounts)}, Accounts: testAccounts}
+
+	result, err := Import(context.Background(), client.Accounts, snap, &ImportOptions{OrganisationID: "org"})
+	if err != nil {
+		t.Fatalf("Import returned error: %v", err)
+	}
+	if len(result.Failed) != 0 {
+		t.Errorf("Import failed for %v", result.Failed)
+	}
+	if want := []string{*testAccounts[0].ID, *testAccounts[1].ID}; !reflect.DeepEqual(result.Created, want) {
+		t.Errorf("Import created %v, want %v", result.Created, want)
+	}
+	for _, a := range created() {
+		if a.Version != nil {
+			t.Errorf("Account %v created with version %v, want none", *a.ID, *a.Version)
+		}
+		if *a.OrganisationId != "org" {
+			t.Errorf("Account %v created in organisation %v, want org", *a.ID, *a.OrganisationId)
+		}
+	}
+	if *testAccounts[1].Version != 3 {
+		t.Errorf("Import modified the snapshot's accounts")
+	}
+}
+
+func TestUnit_Import_NewIDs(t *testing.T) {
+	client, created := setupClient(t, nil)
+	snap := &Snapshot{Accounts: testAccounts}
+
+	result, err := Import(context.Background(), client.Accounts, snap, &ImportOptions{NewIDs: true})
+	if err != nil {
+		t.Fatalf("Import returned error: %v", err)
+	}
+	for i, a := range created() {
+		if *a.ID == *testAccounts[i].ID {
+			t.Errorf("Account created with its exported ID %v", *a.ID)
+		}
+		if *a.ID != result.Created[i] {
+			t.Errorf("Result reports ID %v, want %v", result.Created[i], *a.ID)
+		}
+	}
+}
+
+func TestUnit_Import_Masked(t *testing.T) {
+	client, _ := setupClient(t, nil)
+	snap := &Snapshot{Manifest: Manifest{Masked: true}, Accounts: testAccounts}
+
+	if _, err := Import(context.Background(), client.Accounts, snap, nil); err == nil {
+		t.Errorf("Expected error importing a masked snapshot")
+	}
+}
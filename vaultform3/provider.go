@@ -0,0 +1,255 @@
+package vaultform3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"form3.tech/go-form3/form3"
+)
+
+// defaultRefreshInterval is how often credentials are read from Vault
+// unless ProviderOptions says otherwise.
+const defaultRefreshInterval = time.Minute
+
+// refreshTimeout bounds a refresh made in the background.
+const refreshTimeout = 30 * time.Second
+
+// renewRetryInterval is the least time between attempts to renew the Vault
+// token once it is due, so that a failing Vault is not retried constantly.
+const renewRetryInterval = time.Second
+
+// Fields of the KV secret read by CredentialsProvider. Each is optional, but
+// together they must make complete form3.Credentials.
+const (
+	FieldClientID     = "client_id"
+	FieldClientSecret = "client_secret"
+	FieldSigningKeyID = "signing_key_id"
+	FieldSigningKey   = "signing_key" // PEM encoded RSA private key
+)
+
+// ProviderOptions specifies where a CredentialsProvider finds credentials.
+// At least one of KVPath and TransitKey must be set.
+type ProviderOptions struct {
+	// Mount of the KV version 2 secrets engine. Defaults to "secret".
+	KVMount string
+
+	// Path of the secret holding credentials in the KV secrets engine,
+	// with the fields named by the Field constants.
+	KVPath string
+
+	// Mount of the Transit secrets engine. Defaults to "transit".
+	TransitMount string
+
+	// Name of the Transit key that signs requests. If set, it is used
+	// instead of any signing key in the KV secret.
+	TransitKey string
+
+	// ID by which Form3 knows the public key of TransitKey. Defaults to the
+	// signing_key_id field of the KV secret.
+	SigningKeyID string
+
+	// How often the KV secret is read again to pick up rotated
+	// credentials. Defaults to a minute. The Vault token is renewed in
+	// between if it is due to expire sooner.
+	RefreshInterval time.Duration
+
+	// How long credentials are used for once they can no longer be read
+	// again, after which requests fail. Defaults to no limit.
+	MaxAge time.Duration
+}
+
+// A CredentialsProvider is a form3.CredentialsProvider reading credentials
+// from Vault. It refreshes them, and renews the Vault token, in the
+// background until it is closed. It is safe for concurrent use.
+type CredentialsProvider struct {
+	client  *Client
+	opts    ProviderOptions
+	transit *TransitSigner
+	stop    chan struct{}
+	done    chan struct{}
+
+	mu          sync.Mutex
+	credentials *form3.Credentials
+	readAt      time.Time // when credentials were last read
+	lastErr     error
+	signingKey  string
+	signer      form3.Signer
+}
+
+// NewCredentialsProvider returns a provider of credentials from Vault,
+// reading them once to check that they are complete. It must be closed to
+// stop refreshing them.
+func NewCredentialsProvider(client *Client, opts *ProviderOptions) (*CredentialsProvider, error) {
+	p := &CredentialsProvider{client: client, stop: make(chan struct{}), done: make(chan struct{})}
+	if opts != nil {
+		p.opts = *opts
+	}
+	if p.opts.KVPath == "" && p.opts.TransitKey == "" {
+		return nil, errors.New("a KV path or a Transit key must be given")
+	}
+	if p.opts.KVMount == "" {
+		p.opts.KVMount = "secret"
+	}
+	if p.opts.TransitMount == "" {
+		p.opts.TransitMount = "transit"
+	}
+	if p.opts.RefreshInterval <= 0 {
+		p.opts.RefreshInterval = defaultRefreshInterval
+	}
+	if p.opts.TransitKey != "" {
+		p.transit = NewTransitSigner(client, p.opts.TransitMount, p.opts.TransitKey)
+	}
+
+	if err := p.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	go p.run()
+	return p, nil
+}
+
+// Close stops refreshing the credentials and renewing the Vault token.
+func (p *CredentialsProvider) Close() error {
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+	<-p.done
+	return nil
+}
+
+// run refreshes the credentials every refresh interval, and renews the
+// Vault token whenever it is due, whether or not credentials are asked for,
+// until p is closed.
+func (p *CredentialsProvider) run() {
+	defer close(p.done)
+	for {
+		wait := p.opts.RefreshInterval
+		if due := p.client.renewalDue(); !due.IsZero() {
+			untilDue := time.Until(due)
+			if untilDue < renewRetryInterval {
+				untilDue = renewRetryInterval
+			}
+			if untilDue < wait {
+				wait = untilDue
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-p.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+		p.Refresh(ctx)
+		cancel()
+	}
+}
+
+// Credentials returns the credentials last read from Vault, without waiting
+// for Vault. If they could not be read again, for instance because Vault is
+// unavailable, the previous credentials are returned and the error is
+// reported by LastError; once the previous credentials are older than the
+// MaxAge option, the error is returned instead.
+func (p *CredentialsProvider) Credentials(ctx context.Context) (*form3.Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.opts.MaxAge > 0 && time.Since(p.readAt) > p.opts.MaxAge {
+		return nil, fmt.Errorf("credentials from vault are out of date, last read at %v: %w", p.readAt.Format(time.RFC3339), p.lastErr)
+	}
+	return p.credentials, nil
+}
+
+// LastError returns the error of the last refresh, or nil if it succeeded.
+func (p *CredentialsProvider) LastError() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastErr
+}
+
+// Refresh renews the Vault token if it is due and reads the credentials
+// from Vault now, returning an error if they cannot be read or are
+// incomplete, in which case the previous credentials are kept.
+func (p *CredentialsProvider) Refresh(ctx context.Context) error {
+	p.mu.Lock()
+	signingKey, signer := p.signingKey, p.signer
+	p.mu.Unlock()
+
+	credentials, signingKey, err := p.read(ctx, signingKey, signer)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastErr = err
+	if err != nil {
+		return err
+	}
+	p.credentials, p.readAt = credentials, time.Now()
+	p.signingKey, p.signer = signingKey, credentials.Signer
+	return nil
+}
+
+// read reads the credentials from Vault, reusing signer if the signing key
+// read is still prevKey. It returns the credentials and the signing key.
+func (p *CredentialsProvider) read(ctx context.Context, prevKey string, signer form3.Signer) (*form3.Credentials, string, error) {
+	// The token is checked even if no secret is read, so that it is
+	// renewed for the Transit signer.
+	if _, err := p.client.currentToken(ctx); err != nil {
+		return nil, "", err
+	}
+
+	credentials := &form3.Credentials{SigningKeyID: p.opts.SigningKeyID}
+	var signingKey string
+	if p.opts.KVPath != "" {
+		data, err := p.client.ReadKV(ctx, p.opts.KVMount, p.opts.KVPath)
+		if err != nil {
+			return nil, "", fmt.Errorf("reading credentials from vault: %w", err)
+		}
+		fields := map[string]*string{
+			FieldClientID:     &credentials.ClientID,
+			FieldClientSecret: &credentials.ClientSecret,
+			FieldSigningKey:   &signingKey,
+		}
+		if credentials.SigningKeyID == "" {
+			fields[FieldSigningKeyID] = &credentials.SigningKeyID
+		}
+		for name, field := range fields {
+			if v, ok := data[name]; ok {
+				s, ok := v.(string)
+				if !ok {
+					return nil, "", fmt.Errorf("vault secret field %s is a %T, not a string", name, v)
+				}
+				*field = s
+			}
+		}
+	}
+
+	switch {
+	case p.transit != nil:
+		credentials.Signer = p.transit
+	case signingKey == prevKey:
+		credentials.Signer = signer
+	case signingKey != "":
+		key, err := form3.ParseRSAPrivateKey([]byte(signingKey))
+		if err != nil {
+			return nil, "", fmt.Errorf("reading signing key from vault: %v", err)
+		}
+		credentials.Signer = form3.NewRSASigner(key)
+	}
+	if credentials.ClientID == "" && credentials.ClientSecret == "" && credentials.Signer == nil {
+		return nil, "", errors.New("no credentials found in vault")
+	}
+	if (credentials.ClientID != "") != (credentials.ClientSecret != "") {
+		return nil, "", errors.New("credentials in vault must have both a client ID and a client secret")
+	}
+	if (credentials.SigningKeyID != "") != (credentials.Signer != nil) {
+		return nil, "", errors.New("credentials in vault must have both a signing key ID and a signing key")
+	}
+	return credentials, signingKey, nil
+}
@@ -0,0 +1,272 @@
+package vaultform3
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"form3.tech/go-form3/form3"
+)
+
+func pemEncode(key *rsa.PrivateKey) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+func TestUnit_CredentialsProvider_KV(t *testing.T) {
+	vault, client := newFakeVault(t)
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	vault.secrets["form3"] = map[string]interface{}{
+		FieldClientID:     "client",
+		FieldClientSecret: "secret-1",
+		FieldSigningKeyID: "key-1",
+		FieldSigningKey:   pemEncode(key),
+	}
+
+	p, err := NewCredentialsProvider(client, &ProviderOptions{KVPath: "form3"})
+	if err != nil {
+		t.Fatalf("NewCredentialsProvider returned error: %v", err)
+	}
+	defer p.Close()
+	first, _ := p.Credentials(context.Background())
+	if first.ClientID != "client" || first.ClientSecret != "secret-1" || first.SigningKeyID != "key-1" || first.Signer == nil {
+		t.Errorf("Credentials returned %+v, want those in vault", first)
+	}
+
+	vault.mu.Lock()
+	vault.secrets["form3"][FieldClientSecret] = "secret-2"
+	vault.mu.Unlock()
+	if err := p.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	second, _ := p.Credentials(context.Background())
+	if second.ClientSecret != "secret-2" {
+		t.Errorf("Credentials returned secret %v after rotation, want secret-2", second.ClientSecret)
+	}
+	if second.Signer != first.Signer {
+		t.Error("Credentials parsed the unchanged signing key again")
+	}
+	if first.ClientSecret != "secret-1" {
+		t.Error("Credentials modified credentials returned earlier")
+	}
+
+	vault.mu.Lock()
+	vault.unavailable = true
+	vault.mu.Unlock()
+	if err := p.Refresh(context.Background()); err == nil {
+		t.Error("Refresh did not return error while vault is unavailable")
+	}
+	if got, err := p.Credentials(context.Background()); err != nil || got != second {
+		t.Errorf("Credentials returned %+v, %v while vault is unavailable, want the previous credentials", got, err)
+	}
+	var vaultErr *Error
+	if err := p.LastError(); !errors.As(err, &vaultErr) || vaultErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("LastError returned %v, want 503 error", err)
+	}
+}
+
+func TestUnit_CredentialsProvider_BackgroundRefresh(t *testing.T) {
+	vault, client := newFakeVault(t)
+	vault.secrets["form3"] = map[string]interface{}{FieldClientID: "client", FieldClientSecret: "secret-1"}
+
+	p, err := NewCredentialsProvider(client, &ProviderOptions{KVPath: "form3", RefreshInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewCredentialsProvider returned error: %v", err)
+	}
+	defer p.Close()
+	vault.mu.Lock()
+	vault.secrets["form3"][FieldClientSecret] = "secret-2"
+	vault.mu.Unlock()
+
+	// The refresh must not depend on requests.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := p.Credentials(ctx)
+		if err != nil {
+			t.Fatalf("Credentials returned error: %v", err)
+		}
+		if got.ClientSecret == "secret-2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Credentials returned secret %v after rotation, want secret-2; last refresh error: %v", got.ClientSecret, p.LastError())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestUnit_CredentialsProvider_RenewsIdleToken(t *testing.T) {
+	vault, client := newFakeVault(t)
+	vault.ttl, vault.renewable = 61, true
+	vault.secrets["form3"] = map[string]interface{}{FieldClientID: "client", FieldClientSecret: "secret"}
+
+	p, err := NewCredentialsProvider(client, &ProviderOptions{KVPath: "form3", RefreshInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewCredentialsProvider returned error: %v", err)
+	}
+	defer p.Close()
+
+	// The token is due for renewal a second later, with no requests made.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		vault.mu.Lock()
+		renewals := vault.renewals
+		vault.mu.Unlock()
+		if renewals > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Token was not renewed while idle; last refresh error: %v", p.LastError())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := p.LastError(); err != nil {
+		t.Errorf("LastError returned %v after renewal, want nil", err)
+	}
+}
+
+func TestUnit_CredentialsProvider_Close(t *testing.T) {
+	vault, client := newFakeVault(t)
+	vault.secrets["form3"] = map[string]interface{}{FieldClientID: "client", FieldClientSecret: "secret"}
+
+	p, err := NewCredentialsProvider(client, &ProviderOptions{KVPath: "form3", RefreshInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewCredentialsProvider returned error: %v", err)
+	}
+	p.Close()
+	vault.mu.Lock()
+	reads := vault.reads
+	vault.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+	vault.mu.Lock()
+	defer vault.mu.Unlock()
+	if vault.reads != reads {
+		t.Errorf("Read the secret %d times after Close, want none", vault.reads-reads)
+	}
+	p.Close()
+}
+
+func TestUnit_CredentialsProvider_MaxAge(t *testing.T) {
+	vault, client := newFakeVault(t)
+	vault.secrets["form3"] = map[string]interface{}{FieldClientID: "client", FieldClientSecret: "secret"}
+
+	p, err := NewCredentialsProvider(client, &ProviderOptions{KVPath: "form3", RefreshInterval: time.Hour, MaxAge: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewCredentialsProvider returned error: %v", err)
+	}
+	defer p.Close()
+	vault.mu.Lock()
+	vault.unavailable = true
+	vault.mu.Unlock()
+	p.Refresh(context.Background())
+	time.Sleep(2 * time.Millisecond)
+
+	_, err = p.Credentials(context.Background())
+	var vaultErr *Error
+	if !errors.As(err, &vaultErr) || vaultErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Credentials returned error %v once out of date, want the 503 error of the refresh", err)
+	}
+}
+
+func TestUnit_CredentialsProvider_RefreshInterval(t *testing.T) {
+	vault, client := newFakeVault(t)
+	vault.secrets["form3"] = map[string]interface{}{FieldClientID: "client", FieldClientSecret: "secret"}
+
+	p, err := NewCredentialsProvider(client, &ProviderOptions{KVPath: "form3"})
+	if err != nil {
+		t.Fatalf("NewCredentialsProvider returned error: %v", err)
+	}
+	defer p.Close()
+	for i := 0; i < 3; i++ {
+		p.Credentials(context.Background())
+	}
+	vault.mu.Lock()
+	defer vault.mu.Unlock()
+	if vault.reads != 1 {
+		t.Errorf("Read the secret %d times within the refresh interval, want 1", vault.reads)
+	}
+}
+
+func TestUnit_NewCredentialsProvider_Invalid(t *testing.T) {
+	vault, client := newFakeVault(t)
+	vault.secrets["client-id-only"] = map[string]interface{}{FieldClientID: "client"}
+	vault.secrets["key-without-id"] = map[string]interface{}{FieldSigningKey: "not a key"}
+	vault.secrets["not-a-string"] = map[string]interface{}{FieldClientID: 1, FieldClientSecret: "secret"}
+	vault.secrets["empty"] = map[string]interface{}{}
+
+	tests := []struct {
+		name string
+		opts *ProviderOptions
+	}{
+		{"no options", nil},
+		{"missing secret", &ProviderOptions{KVPath: "missing"}},
+		{"client ID without secret", &ProviderOptions{KVPath: "client-id-only"}},
+		{"invalid signing key", &ProviderOptions{KVPath: "key-without-id"}},
+		{"non-string field", &ProviderOptions{KVPath: "not-a-string"}},
+		{"empty secret", &ProviderOptions{KVPath: "empty"}},
+		{"transit key without ID", &ProviderOptions{TransitKey: "form3-signing"}},
+	}
+	for _, tt := range tests {
+		if _, err := NewCredentialsProvider(client, tt.opts); err == nil {
+			t.Errorf("NewCredentialsProvider with %s did not return error", tt.name)
+		}
+	}
+}
+
+var signatureParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+func TestUnit_CredentialsProvider_Transit(t *testing.T) {
+	vault, vaultClient := newFakeVault(t)
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	vault.keys["form3-signing"] = key
+
+	p, err := NewCredentialsProvider(vaultClient, &ProviderOptions{TransitKey: "form3-signing", SigningKeyID: "key-1"})
+	if err != nil {
+		t.Fatalf("NewCredentialsProvider returned error: %v", err)
+	}
+	defer p.Close()
+
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"data": {}}`)
+	}))
+	defer server.Close()
+	client, err := form3.NewClientWithOptions(form3.WithBaseURL(server.URL+"/v1/"), form3.WithCredentialsProvider(p))
+	if err != nil {
+		t.Fatalf("NewClientWithOptions returned error: %v", err)
+	}
+	if _, _, err := client.Accounts.Fetch(context.Background(), "1"); err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+
+	params := make(map[string]string)
+	for _, m := range signatureParam.FindAllStringSubmatch(signature, -1) {
+		params[m[1]] = m[2]
+	}
+	if params["keyId"] != "key-1" {
+		t.Errorf("Request signed with key %q, want key-1", params["keyId"])
+	}
+	if len(vault.signed) != 1 {
+		t.Fatalf("Vault signed %d times, want 1", len(vault.signed))
+	}
+	sig, _ := base64.StdEncoding.DecodeString(params["signature"])
+	digest := sha256.Sum256(vault.signed[0])
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+		t.Errorf("Request signature is not the one made by vault: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package vaultform3
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestUnit_TransitSigner(t *testing.T) {
+	vault, client := newFakeVault(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vault.keys["form3-signing"] = key
+
+	signer := NewTransitSigner(client, "transit", "form3-signing")
+	if got, want := signer.Algorithm(), "rsa-sha256"; got != want {
+		t.Errorf("Algorithm returned %v, want %v", got, want)
+	}
+
+	data := []byte("(request-target): get /v1/organisation/accounts")
+	signature, err := signer.Sign(context.Background(), data)
+	if err != nil {
+		t.Fatalf("Sign returned error: %v", err)
+	}
+	digest := sha256.Sum256(data)
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("Signature does not verify: %v", err)
+	}
+
+	if _, err := NewTransitSigner(client, "transit", "missing").Sign(context.Background(), data); err == nil {
+		t.Error("Sign with a missing key did not return error")
+	}
+}
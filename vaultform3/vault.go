@@ -0,0 +1,237 @@
+// Package vaultform3 provides Form3 API credentials from HashiCorp Vault.
+//
+// Client credentials and signing keys can be read from a KV version 2
+// secret, and requests can be signed by a Transit key so that the private
+// key never leaves Vault:
+//
+//	vault, err := vaultform3.NewClientFromEnv()
+//	provider, err := vaultform3.NewCredentialsProvider(vault, &vaultform3.ProviderOptions{
+//		KVPath:       "form3/credentials",
+//		TransitKey:   "form3-signing",
+//		SigningKeyID: "75a8ba12-fff2-4a52-ad8a-e8b34c5ccec8",
+//	})
+//	defer provider.Close()
+//	client, err := form3.NewClientWithOptions(form3.WithCredentialsProvider(provider))
+//
+// The Vault token is renewed shortly before it expires, if it is renewable.
+// A CredentialsProvider renews it in the background, so that it does not
+// expire while the process is idle, until the provider is closed.
+package vaultform3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables read by NewClientFromEnv, as used by the Vault CLI.
+const (
+	EnvAddress = "VAULT_ADDR"
+	EnvToken   = "VAULT_TOKEN"
+)
+
+// tokenRenewMargin is how long before its expiry the Vault token is renewed.
+const tokenRenewMargin = time.Minute
+
+// Config specifies how to connect to Vault.
+type Config struct {
+	// Address of the Vault server, e.g. "http://localhost:8200".
+	Address string
+
+	// Token to authenticate with.
+	Token string
+
+	// HTTP client used to communicate with Vault. Defaults to a new
+	// http.Client.
+	HTTPClient *http.Client
+}
+
+// A Client makes requests to the Vault HTTP API. It is safe for concurrent
+// use.
+type Client struct {
+	address    *url.URL
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time // zero if the token does not expire
+	renewable   bool
+	looked      bool // whether the token has been looked up
+}
+
+// NewClient returns a new Vault client configured by cfg.
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("vault address must be non-empty")
+	}
+	if cfg.Token == "" {
+		return nil, errors.New("vault token must be non-empty")
+	}
+	u, err := url.Parse(cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault address: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("vault address %q must use http or https", cfg.Address)
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	return &Client{address: u, httpClient: httpClient, token: cfg.Token}, nil
+}
+
+// NewClientFromEnv returns a new Vault client for the server at VAULT_ADDR,
+// authenticated with VAULT_TOKEN.
+func NewClientFromEnv() (*Client, error) {
+	return NewClient(Config{Address: os.Getenv(EnvAddress), Token: os.Getenv(EnvToken)})
+}
+
+// An Error is returned when Vault responds with a status code outside the
+// 200 range.
+type Error struct {
+	StatusCode int
+	Errors     []string `json:"errors"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("vault: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("vault: %d %s", e.StatusCode, strings.Join(e.Errors, "; "))
+}
+
+// tokenInfo is the data of a token lookup or renewal response.
+type tokenInfo struct {
+	TTL       int  `json:"ttl"`            // seconds, from lookup-self
+	LeaseTTL  int  `json:"lease_duration"` // seconds, from renew-self
+	Renewable bool `json:"renewable"`
+}
+
+// currentToken returns the token to authenticate a request with, renewing
+// it first if it is renewable and about to expire. The token is looked up
+// on first use to learn its expiry.
+func (c *Client) currentToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.looked {
+		resp := new(struct {
+			Data tokenInfo `json:"data"`
+		})
+		if err := c.send(ctx, c.token, "GET", "auth/token/lookup-self", nil, resp); err != nil {
+			return "", fmt.Errorf("looking up vault token: %w", err)
+		}
+		c.setExpiry(resp.Data.TTL, resp.Data.Renewable)
+		c.looked = true
+	}
+
+	if c.renewable && !c.tokenExpiry.IsZero() && time.Until(c.tokenExpiry) < tokenRenewMargin {
+		resp := new(struct {
+			Auth tokenInfo `json:"auth"`
+		})
+		if err := c.send(ctx, c.token, "POST", "auth/token/renew-self", struct{}{}, resp); err != nil {
+			return "", fmt.Errorf("renewing vault token: %w", err)
+		}
+		c.setExpiry(resp.Auth.LeaseTTL, resp.Auth.Renewable)
+	}
+	return c.token, nil
+}
+
+// renewalDue returns when the token is due to be renewed, or the zero time
+// if it is not renewable, does not expire or has not been looked up.
+func (c *Client) renewalDue() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.renewable || c.tokenExpiry.IsZero() {
+		return time.Time{}
+	}
+	return c.tokenExpiry.Add(-tokenRenewMargin)
+}
+
+// setExpiry records the time to live of the token. c.mu must be held.
+func (c *Client) setExpiry(ttl int, renewable bool) {
+	c.tokenExpiry = time.Time{}
+	if ttl > 0 {
+		c.tokenExpiry = time.Now().Add(time.Duration(ttl) * time.Second)
+	}
+	c.renewable = renewable
+}
+
+// do sends a request to the Vault API at path, relative to /v1/, JSON
+// encoding body if it is non-nil and decoding the response into v.
+func (c *Client) do(ctx context.Context, method, path string, body, v interface{}) error {
+	token, err := c.currentToken(ctx)
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, token, method, path, body, v)
+}
+
+func (c *Client) send(ctx context.Context, token, method, path string, body, v interface{}) error {
+	u, err := c.address.Parse("/v1/" + strings.TrimPrefix(path, "/"))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, u.String(), &buf)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Vault-Token", token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		vaultErr := &Error{StatusCode: resp.StatusCode}
+		if data, err := ioutil.ReadAll(resp.Body); err == nil {
+			json.Unmarshal(data, vaultErr)
+		}
+		return vaultErr
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// ReadKV returns the latest version of the data of the secret at path in
+// the KV version 2 secrets engine mounted at mount.
+func (c *Client) ReadKV(ctx context.Context, mount, path string) (map[string]interface{}, error) {
+	resp := new(struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	})
+	p := strings.Trim(mount, "/") + "/data/" + strings.TrimPrefix(path, "/")
+	if err := c.do(ctx, "GET", p, nil, resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.Data == nil {
+		return nil, fmt.Errorf("vault secret %s/%s has no data", strings.Trim(mount, "/"), path)
+	}
+	return resp.Data.Data, nil
+}
@@ -0,0 +1,210 @@
+package vaultform3
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testToken = "8fb95528-57c6-422e-9722-d2147bcba8ed"
+
+// fakeVault stands in for a Vault server with a KV version 2 engine mounted
+// at secret and a Transit engine mounted at transit.
+type fakeVault struct {
+	t *testing.T
+
+	mu          sync.Mutex
+	ttl         int
+	renewable   bool
+	unavailable bool
+	secrets     map[string]map[string]interface{}
+	keys        map[string]*rsa.PrivateKey
+	lookups     int
+	renewals    int
+	reads       int
+	signed      [][]byte
+}
+
+// newFakeVault starts a fake Vault server with a non-expiring token, and
+// returns it with a client for it.
+func newFakeVault(t *testing.T) (*fakeVault, *Client) {
+	t.Helper()
+	v := &fakeVault{t: t, secrets: make(map[string]map[string]interface{}), keys: make(map[string]*rsa.PrivateKey)}
+	server := httptest.NewServer(http.HandlerFunc(v.serveHTTP))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(Config{Address: server.URL, Token: testToken})
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	return v, client
+}
+
+func (v *fakeVault) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	reply := func(status int, body interface{}) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+	if v.unavailable {
+		reply(http.StatusServiceUnavailable, map[string][]string{"errors": {"Vault is sealed"}})
+		return
+	}
+	if r.Header.Get("X-Vault-Token") != testToken {
+		reply(http.StatusForbidden, map[string][]string{"errors": {"permission denied"}})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	switch {
+	case path == "auth/token/lookup-self" && r.Method == "GET":
+		v.lookups++
+		reply(http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"ttl": v.ttl, "renewable": v.renewable}})
+
+	case path == "auth/token/renew-self" && r.Method == "POST":
+		v.renewals++
+		v.ttl = 3600
+		reply(http.StatusOK, map[string]interface{}{"auth": map[string]interface{}{"lease_duration": v.ttl, "renewable": true}})
+
+	case strings.HasPrefix(path, "secret/data/") && r.Method == "GET":
+		v.reads++
+		data, ok := v.secrets[strings.TrimPrefix(path, "secret/data/")]
+		if !ok {
+			reply(http.StatusNotFound, map[string][]string{"errors": {}})
+			return
+		}
+		reply(http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"data": data, "metadata": map[string]int{"version": 1}}})
+
+	case strings.HasPrefix(path, "transit/sign/") && r.Method == "POST":
+		name, hash, _ := strings.Cut(strings.TrimPrefix(path, "transit/sign/"), "/")
+		key, ok := v.keys[name]
+		if !ok || hash != "sha2-256" {
+			reply(http.StatusBadRequest, map[string][]string{"errors": {"signing key not found"}})
+			return
+		}
+		var body struct {
+			Input              string `json:"input"`
+			SignatureAlgorithm string `json:"signature_algorithm"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.SignatureAlgorithm != "pkcs1v15" {
+			v.t.Errorf("Signature algorithm = %v, want pkcs1v15", body.SignatureAlgorithm)
+		}
+		input, _ := base64.StdEncoding.DecodeString(body.Input)
+		v.signed = append(v.signed, input)
+		digest := sha256.Sum256(input)
+		signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		reply(http.StatusOK, map[string]interface{}{"data": map[string]string{"signature": "vault:v1:" + base64.StdEncoding.EncodeToString(signature)}})
+
+	default:
+		reply(http.StatusNotFound, map[string][]string{"errors": {}})
+	}
+}
+
+func TestUnit_NewClient_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"no address", Config{Token: testToken}},
+		{"no token", Config{Address: "http://localhost:8200"}},
+		{"address without scheme", Config{Address: "localhost:8200", Token: testToken}},
+	}
+	for _, tt := range tests {
+		if _, err := NewClient(tt.cfg); err == nil {
+			t.Errorf("NewClient with %s did not return error", tt.name)
+		}
+	}
+}
+
+func TestUnit_NewClientFromEnv(t *testing.T) {
+	t.Setenv(EnvAddress, "http://vault:8200")
+	t.Setenv(EnvToken, testToken)
+
+	c, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv returned error: %v", err)
+	}
+	if got, want := c.address.String(), "http://vault:8200"; got != want {
+		t.Errorf("Address is %v, want %v", got, want)
+	}
+	if c.token != testToken {
+		t.Errorf("Token is %v, want %v", c.token, testToken)
+	}
+}
+
+func TestUnit_Client_ReadKV(t *testing.T) {
+	vault, client := newFakeVault(t)
+	vault.secrets["form3"] = map[string]interface{}{"client_id": "client"}
+
+	data, err := client.ReadKV(context.Background(), "secret", "form3")
+	if err != nil {
+		t.Fatalf("ReadKV returned error: %v", err)
+	}
+	if data["client_id"] != "client" {
+		t.Errorf("ReadKV returned %v, want the secret's data", data)
+	}
+
+	_, err = client.ReadKV(context.Background(), "secret", "missing")
+	var vaultErr *Error
+	if !errors.As(err, &vaultErr) || vaultErr.StatusCode != http.StatusNotFound {
+		t.Errorf("ReadKV of a missing secret returned error %v, want 404 error", err)
+	}
+}
+
+func TestUnit_Client_PermissionDenied(t *testing.T) {
+	_, client := newFakeVault(t)
+	client.token = "wrong"
+
+	_, err := client.ReadKV(context.Background(), "secret", "form3")
+	var vaultErr *Error
+	if !errors.As(err, &vaultErr) || vaultErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("ReadKV returned error %v, want 403 error", err)
+	}
+	if !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Error %q does not include the errors from vault", err)
+	}
+}
+
+func TestUnit_Client_TokenRenewal(t *testing.T) {
+	tests := []struct {
+		name         string
+		ttl          int
+		renewable    bool
+		wantRenewals int
+	}{
+		{"non-expiring", 0, false, 0},
+		{"expiring", 3600, true, 0},
+		{"about to expire", 30, true, 1},
+		{"about to expire, not renewable", 30, false, 0},
+	}
+	for _, tt := range tests {
+		vault, client := newFakeVault(t)
+		vault.ttl, vault.renewable = tt.ttl, tt.renewable
+		vault.secrets["form3"] = map[string]interface{}{}
+
+		for i := 0; i < 3; i++ {
+			if _, err := client.ReadKV(context.Background(), "secret", "form3"); err != nil {
+				t.Fatalf("ReadKV with %s token returned error: %v", tt.name, err)
+			}
+		}
+		if vault.lookups != 1 {
+			t.Errorf("With %s token, looked up token %d times, want 1", tt.name, vault.lookups)
+		}
+		if vault.renewals != tt.wantRenewals {
+			t.Errorf("With %s token, renewed token %d times, want %d", tt.name, vault.renewals, tt.wantRenewals)
+		}
+	}
+}